@@ -36,14 +36,24 @@ func AuthorFromInteraction(h *Harmonia, i *discordgo.Interaction) (a *Author, er
 
 // AuthorFromMember returns an Author from a *discordgo.Member.
 func AuthorFromMember(h *Harmonia, member *discordgo.Member) (*Author, error) {
-	guild, err := h.Guild(member.GuildID)
+	guild, err := h.guild(member.GuildID)
 	if err != nil {
 		return nil, err
 	}
 
-	roles, err := RolesFromMember(h, member)
-	if err != nil {
-		return nil, err
+	return authorFromMember(h, guild, member)
+}
+
+func authorFromMember(h *Harmonia, guild *discordgo.Guild, member *discordgo.Member) (*Author, error) {
+	var roles []*discordgo.Role
+	if len(guild.Roles) > 0 {
+		roles = rolesFromGuild(guild.Roles, member)
+	} else {
+		var err error
+		roles, err = RolesFromMember(h, member)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	a := &Author{User: member.User,
@@ -60,21 +70,167 @@ func AuthorFromMember(h *Harmonia, member *discordgo.Member) (*Author, error) {
 	return a, nil
 }
 
+// BotAuthor returns the Author of the bot in the guild with the given ID, to check its permissions and place in the role hierarchy.
+func BotAuthor(h *Harmonia, guildID string) (*Author, error) {
+	guild, err := h.guild(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := h.botMember(guildID)
+	if err != nil {
+		return nil, err
+	}
+	return authorFromMember(h, guild, member)
+}
+
 // RolesFromMember returns a slice of *discordgo.Role from a *discordgo.Member.
+// The roles of the guild are taken from the State cache when possible, only falling back to the Discord API when they are not cached.
 func RolesFromMember(h *Harmonia, member *discordgo.Member) ([]*discordgo.Role, error) {
-	guildroles, err := h.GuildRoles(member.GuildID)
+	guildroles, err := h.guildRoles(member.GuildID)
 	if err != nil {
 		return nil, err
 	}
 
+	return rolesFromGuild(guildroles, member), nil
+}
+
+func rolesFromGuild(guildroles []*discordgo.Role, member *discordgo.Member) []*discordgo.Role {
+	rolemap := make(map[string]*discordgo.Role, len(guildroles))
+	for _, role := range guildroles {
+		rolemap[role.ID] = role
+	}
+
 	roles := make([]*discordgo.Role, 0, len(member.Roles))
 	for _, roleid := range member.Roles {
-		for _, role := range guildroles {
-			if role.ID == roleid {
-				roles = append(roles, role)
+		if role, ok := rolemap[roleid]; ok {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles
+}
+
+func (h *Harmonia) guildRoles(guildID string) ([]*discordgo.Role, error) {
+	if guild, err := h.state().Guild(guildID); err == nil && len(guild.Roles) > 0 {
+		return guild.Roles, nil
+	}
+	return h.RESTClient().GuildRoles(guildID)
+}
+
+// IsOwner returns whether the Author owns the Guild it is a member of.
+func (a *Author) IsOwner() bool {
+	return a.IsMember && a.Guild != nil && a.Guild.OwnerID == a.ID
+}
+
+// Permissions computes the effective permissions of the Author in the given channel, taking the channel's permission overwrites into account.
+// If channel is nil, only the guild-wide permissions of the Author are computed.
+func (a *Author) Permissions(channel *discordgo.Channel) int64 {
+	if !a.IsMember {
+		return 0
+	}
+
+	if a.IsOwner() {
+		return allPermissions
+	}
+
+	var perms int64
+	if a.Guild != nil {
+		for _, role := range a.Guild.Roles {
+			if role.ID == a.Guild.ID {
+				perms |= role.Permissions
+				break
 			}
 		}
 	}
 
-	return roles, nil
+	for _, role := range a.Roles {
+		perms |= role.Permissions
+	}
+
+	if perms&discordgo.PermissionAdministrator != 0 {
+		return allPermissions
+	}
+
+	if channel == nil {
+		return perms
+	}
+
+	var everyone, member *discordgo.PermissionOverwrite
+	var allow, deny int64
+	for _, overwrite := range channel.PermissionOverwrites {
+		switch {
+		case overwrite.Type == discordgo.PermissionOverwriteTypeRole && overwrite.ID == channel.GuildID:
+			everyone = overwrite
+		case overwrite.Type == discordgo.PermissionOverwriteTypeRole && a.hasRole(overwrite.ID):
+			allow |= overwrite.Allow
+			deny |= overwrite.Deny
+		case overwrite.Type == discordgo.PermissionOverwriteTypeMember && overwrite.ID == a.ID:
+			member = overwrite
+		}
+	}
+
+	if everyone != nil {
+		perms = perms&^everyone.Deny | everyone.Allow
+	}
+	perms = perms&^deny | allow
+	if member != nil {
+		perms = perms&^member.Deny | member.Allow
+	}
+
+	return perms
+}
+
+// HasPermission returns whether the Author has all of the given permissions in the given channel.
+func (a *Author) HasPermission(channel *discordgo.Channel, perms int64) bool {
+	return a.Permissions(channel)&perms == perms
+}
+
+// HighestRole returns the role of the Author that is highest in the role hierarchy, or nil if the Author has no roles.
+func (a *Author) HighestRole() *discordgo.Role {
+	var highest *discordgo.Role
+	for _, role := range a.Roles {
+		if highest == nil || role.Position > highest.Position {
+			highest = role
+		}
+	}
+	return highest
+}
+
+// CanModerate returns whether the Author is above the target in the role hierarchy of their guild, meaning it can kick, ban or change the roles of the target.
+// The owner of a guild can moderate everyone but themselves, and can not be moderated by anyone.
+func (a *Author) CanModerate(target *Author) bool {
+	if !a.IsMember || !target.IsMember || a.ID == target.ID {
+		return false
+	}
+
+	if a.Guild != nil && target.Guild != nil && a.Guild.ID != target.Guild.ID {
+		return false
+	}
+
+	if target.IsOwner() {
+		return false
+	}
+
+	if a.IsOwner() {
+		return true
+	}
+
+	return rolePosition(a.HighestRole()) > rolePosition(target.HighestRole())
+}
+
+func rolePosition(role *discordgo.Role) int {
+	if role == nil {
+		return 0
+	}
+	return role.Position
+}
+
+func (a *Author) hasRole(roleID string) bool {
+	for _, role := range a.Roles {
+		if role.ID == roleID {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,88 @@
+package harmonia
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	everyoneRole  = &discordgo.Role{ID: "guild", Position: 0, Permissions: discordgo.PermissionViewChannel | discordgo.PermissionSendMessages}
+	moderatorRole = &discordgo.Role{ID: "moderator", Position: 2, Permissions: discordgo.PermissionKickMembers | discordgo.PermissionManageMessages}
+	helperRole    = &discordgo.Role{ID: "helper", Position: 1, Permissions: discordgo.PermissionManageMessages}
+	adminRole     = &discordgo.Role{ID: "admin", Position: 3, Permissions: discordgo.PermissionAdministrator}
+
+	testGuild = &discordgo.Guild{
+		ID:      "guild",
+		OwnerID: "owner",
+		Roles:   []*discordgo.Role{everyoneRole, moderatorRole, helperRole, adminRole},
+	}
+)
+
+func testAuthor(id string, roles ...*discordgo.Role) *Author {
+	return &Author{User: &discordgo.User{ID: id}, IsMember: true, Guild: testGuild, Roles: roles}
+}
+
+func TestAuthorPermissions(t *testing.T) {
+	moderator := testAuthor("user", moderatorRole)
+
+	assert.Equal(t, everyoneRole.Permissions|moderatorRole.Permissions, moderator.Permissions(nil))
+	assert.True(t, moderator.HasPermission(nil, discordgo.PermissionKickMembers))
+	assert.False(t, moderator.HasPermission(nil, discordgo.PermissionBanMembers))
+
+	assert.Equal(t, allPermissions, testAuthor("user", adminRole).Permissions(nil))
+	assert.Equal(t, allPermissions, testAuthor("owner").Permissions(nil))
+	assert.Equal(t, int64(0), AuthorFromUser(&discordgo.User{ID: "user"}).Permissions(nil))
+}
+
+func TestAuthorChannelPermissions(t *testing.T) {
+	channel := &discordgo.Channel{
+		ID:      "channel",
+		GuildID: "guild",
+		PermissionOverwrites: []*discordgo.PermissionOverwrite{
+			{ID: "guild", Type: discordgo.PermissionOverwriteTypeRole, Deny: discordgo.PermissionSendMessages},
+			{ID: "moderator", Type: discordgo.PermissionOverwriteTypeRole, Allow: discordgo.PermissionSendMessages},
+			{ID: "helper", Type: discordgo.PermissionOverwriteTypeRole, Deny: discordgo.PermissionManageMessages},
+			{ID: "muted", Type: discordgo.PermissionOverwriteTypeMember, Deny: discordgo.PermissionViewChannel},
+		},
+	}
+
+	assert.False(t, testAuthor("user").HasPermission(channel, discordgo.PermissionSendMessages))
+	assert.True(t, testAuthor("user", moderatorRole).HasPermission(channel, discordgo.PermissionSendMessages))
+	assert.False(t, testAuthor("user", helperRole).HasPermission(channel, discordgo.PermissionManageMessages))
+	assert.False(t, testAuthor("muted", moderatorRole).HasPermission(channel, discordgo.PermissionViewChannel))
+	assert.True(t, testAuthor("muted", adminRole).HasPermission(channel, discordgo.PermissionViewChannel))
+}
+
+func TestAuthorHighestRole(t *testing.T) {
+	assert.Nil(t, testAuthor("user").HighestRole())
+	assert.Equal(t, moderatorRole, testAuthor("user", helperRole, moderatorRole).HighestRole())
+}
+
+func TestAuthorCanModerate(t *testing.T) {
+	owner := testAuthor("owner")
+	admin := testAuthor("admin", adminRole)
+	moderator := testAuthor("moderator", moderatorRole)
+	otherModerator := testAuthor("other", moderatorRole)
+	member := testAuthor("member")
+
+	assert.True(t, owner.CanModerate(admin))
+	assert.False(t, admin.CanModerate(owner))
+	assert.True(t, admin.CanModerate(moderator))
+	assert.False(t, moderator.CanModerate(admin))
+	assert.False(t, moderator.CanModerate(otherModerator))
+	assert.True(t, moderator.CanModerate(member))
+	assert.False(t, member.CanModerate(member))
+	assert.False(t, moderator.CanModerate(AuthorFromUser(&discordgo.User{ID: "user"})))
+}
+
+func TestRolesFromMemberUsesState(t *testing.T) {
+	state := discordgo.NewState()
+	assert.Nil(t, state.GuildAdd(testGuild))
+	harm := &Harmonia{Session: &discordgo.Session{State: state}}
+
+	roles, err := RolesFromMember(harm, &discordgo.Member{GuildID: "guild", Roles: []string{"helper", "admin", "unknown"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []*discordgo.Role{helperRole, adminRole}, roles)
+}
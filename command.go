@@ -1,6 +1,8 @@
 package harmonia
 
 import (
+	"time"
+
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -15,4 +17,51 @@ type CommandHandler interface {
 
 	getRegistration() *discordgo.ApplicationCommand
 	setRegistration(*discordgo.ApplicationCommand)
+	// buildRegistration returns the ApplicationCommand to register the command with, ignoring the registration it already has.
+	buildRegistration() *discordgo.ApplicationCommand
+}
+
+// invoke runs the CommandFunc in its own goroutine, but only after all guards, including those of the groups leading to it, have passed.
+// A panic in the CommandFunc is recovered and logged, so it does not take down the bot.
+func (h *Harmonia) invoke(i *Invocation, guards []Guard, commandFunc CommandFunc) {
+	guards = append(i.guards[:len(i.guards):len(i.guards)], guards...)
+
+	i.invoked = true
+	h.running.add()
+	go func() {
+		defer h.running.done()
+		defer i.endInvocationSpan()
+		defer h.recoverHandler(i)
+		if !h.checkGuards(i, guards) {
+			return
+		}
+
+		start := time.Now()
+		defer func() {
+			h.metrics().HandlerFinished(i.handlerName(), time.Since(start))
+		}()
+		commandFunc(h, i)
+	}()
+}
+
+// resolveSubcommand follows the options of an Interaction from a command to the subcommand that is invoked,
+// returning it with the names of the commands leading to it and its own options.
+func resolveSubcommand(command CommandHandler, options []*discordgo.ApplicationCommandInteractionDataOption) (CommandHandler, []string, []*discordgo.ApplicationCommandInteractionDataOption) {
+	path := []string{command.GetName()}
+	for {
+		group, ok := command.(*GroupSlashCommand)
+		if !ok || len(options) == 0 {
+			break
+		}
+
+		subcommand, ok := group.subcommands[options[0].Name]
+		if !ok {
+			break
+		}
+
+		command = subcommand
+		path = append(path, subcommand.GetName())
+		options = options[0].Options
+	}
+	return command, path, options
 }
@@ -0,0 +1,197 @@
+package harmonia
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A CommandNode describes a command, subcommand group or subcommand in a CommandTree.
+type CommandNode struct {
+	// Path contains the names of the commands leading to the node, ending with its own, such as ["admin", "ban"].
+	Path        []string
+	Description string
+	Type        discordgo.ApplicationCommandType
+
+	// Options are the options of a slash command or subcommand.
+	Options []*Option
+
+	// GuildID, DMPermission and DefaultPermissions are those of the top-level command, as Discord applies them to all its subcommands.
+	GuildID            string
+	DMPermission       bool
+	DefaultPermissions *int64
+
+	// Handler is the CommandHandler that is executed for the node.
+	Handler CommandHandler
+	// Children are the subcommands of a GroupSlashCommand, sorted by name.
+	Children []*CommandNode
+
+	// guards are the guards of the node and the commands leading to it.
+	guards []Guard
+}
+
+// Name returns the full name of the command, such as "admin ban".
+func (n *CommandNode) Name() string {
+	return strings.Join(n.Path, " ")
+}
+
+// IsGroup returns whether the node is a GroupSlashCommand, which can not be executed itself.
+func (n *CommandNode) IsGroup() bool {
+	_, ok := n.Handler.(*GroupSlashCommand)
+	return ok
+}
+
+// Usage returns how the command should be invoked, such as "/admin ban <user> [reason]".
+func (n *CommandNode) Usage() string {
+	if n.Type != discordgo.ChatApplicationCommand {
+		return n.Name()
+	}
+	return "/" + usage(n.Name(), n.Options)
+}
+
+// Allowed returns whether the invoker of the Invocation can use the command, following the guild, DM permission and default permissions
+// Discord uses to show it, and the guards of the command.
+func (n *CommandNode) Allowed(h *Harmonia, i *Invocation) bool {
+	if n.GuildID != "" && n.GuildID != i.GuildID {
+		return false
+	}
+
+	if i.Member == nil && !n.DMPermission {
+		return false
+	}
+
+	if n.DefaultPermissions != nil && i.Member != nil {
+		required := *n.DefaultPermissions
+		if required == 0 {
+			// Commands without default permissions can only be used by administrators.
+			required = discordgo.PermissionAdministrator
+		}
+		if MissingPermissions(i.Member.Permissions, required) != 0 {
+			return false
+		}
+	}
+
+	for _, guard := range n.guards {
+		if guard(h, i) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// A CommandTree is a view of the commands added to Harmonia, with their subcommands, options and permissions.
+type CommandTree struct {
+	// Commands are the top-level commands, sorted by name.
+	Commands []*CommandNode
+}
+
+// CommandTree returns a CommandTree of the commands currently added to Harmonia.
+func (h *Harmonia) CommandTree() *CommandTree {
+	h.handlersMu.RLock()
+	defer h.handlersMu.RUnlock()
+
+	t := &CommandTree{Commands: make([]*CommandNode, 0, len(h.Commands))}
+	for _, command := range h.Commands {
+		t.Commands = append(t.Commands, newCommandNode(command, nil))
+	}
+	sortCommandNodes(t.Commands)
+	return t
+}
+
+// Walk calls walkFunc for every node in the CommandTree, parents before their children.
+func (t *CommandTree) Walk(walkFunc func(n *CommandNode)) {
+	var walk func(nodes []*CommandNode)
+	walk = func(nodes []*CommandNode) {
+		for _, n := range nodes {
+			walkFunc(n)
+			walk(n.Children)
+		}
+	}
+	walk(t.Commands)
+}
+
+// Leaves returns all nodes that can be executed, which are all nodes except for groups.
+func (t *CommandTree) Leaves() []*CommandNode {
+	leaves := make([]*CommandNode, 0)
+	t.Walk(func(n *CommandNode) {
+		if !n.IsGroup() {
+			leaves = append(leaves, n)
+		}
+	})
+	return leaves
+}
+
+// Find returns the node with the given path, such as Find("admin", "ban"), or nil if there is none.
+func (t *CommandTree) Find(path ...string) *CommandNode {
+	var found *CommandNode
+	nodes := t.Commands
+	for _, name := range path {
+		found = nil
+		for _, n := range nodes {
+			if n.Path[len(n.Path)-1] == name {
+				found = n
+				break
+			}
+		}
+		if found == nil {
+			return nil
+		}
+		nodes = found.Children
+	}
+	return found
+}
+
+func newCommandNode(command CommandHandler, parent *CommandNode) *CommandNode {
+	n := &CommandNode{Path: []string{command.GetName()}, Handler: command}
+	if parent != nil {
+		n.Path = append(append([]string{}, parent.Path...), command.GetName())
+		n.guards = append(n.guards, parent.guards...)
+	}
+
+	var guildID string
+	var dmPermission bool
+	var defaultPermissions *int64
+
+	switch c := command.(type) {
+	case *SlashCommand:
+		n.Type = discordgo.ChatApplicationCommand
+		n.Description = c.description
+		n.Options = c.options
+		n.guards = append(n.guards, c.guards...)
+		guildID, dmPermission, defaultPermissions = c.guildID, c.dmPermission, c.defaultPermissions
+	case *GroupSlashCommand:
+		n.Type = discordgo.ChatApplicationCommand
+		n.Description = c.description
+		n.guards = append(n.guards, c.guards...)
+		guildID, dmPermission, defaultPermissions = c.guildID, c.dmPermission, c.defaultPermissions
+	case *UserCommand:
+		n.Type = discordgo.UserApplicationCommand
+		n.guards = append(n.guards, c.guards...)
+		guildID, dmPermission, defaultPermissions = c.guildID, c.dmPermission, c.defaultPermissions
+	case *MessageCommand:
+		n.Type = discordgo.MessageApplicationCommand
+		n.guards = append(n.guards, c.guards...)
+		guildID, dmPermission, defaultPermissions = c.guildID, c.dmPermission, c.defaultPermissions
+	}
+
+	if parent == nil {
+		n.GuildID, n.DMPermission, n.DefaultPermissions = guildID, dmPermission, defaultPermissions
+	} else {
+		n.GuildID, n.DMPermission, n.DefaultPermissions = parent.GuildID, parent.DMPermission, parent.DefaultPermissions
+	}
+
+	if group, ok := command.(*GroupSlashCommand); ok {
+		for _, subcommand := range group.subcommands {
+			n.Children = append(n.Children, newCommandNode(subcommand, n))
+		}
+		sortCommandNodes(n.Children)
+	}
+	return n
+}
+
+func sortCommandNodes(nodes []*CommandNode) {
+	sort.Slice(nodes, func(a, b int) bool {
+		return nodes[a].Name() < nodes[b].Name()
+	})
+}
@@ -0,0 +1,65 @@
+package harmonia
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func testCommandTree() *Harmonia {
+	harm := &Harmonia{Commands: make(map[string]CommandHandler)}
+	harm.AddCommand(NewSlashCommand("ping").WithDescription("Pong").WithDMPermission(true))
+	harm.AddCommand(NewGroupSlashCommand("admin").
+		WithDescription("Moderation").
+		WithDefaultPermissions(discordgo.PermissionBanMembers).
+		WithSubCommands(
+			NewSlashCommand("ban").WithDescription("Bans a user").
+				WithOptions(NewOption("user", discordgo.ApplicationCommandOptionUser).WithDescription("The user to ban").IsRequired()),
+			NewGroupSlashCommand("roles").WithDescription("Manages roles").WithSubCommands(
+				NewSlashCommand("add").WithDescription("Adds a role").WithGuards(RequireRoles("helper")),
+			),
+		))
+	harm.AddCommand(NewUserCommand("Inspect"))
+	return harm
+}
+
+func TestCommandTree(t *testing.T) {
+	tree := testCommandTree().CommandTree()
+
+	names := make([]string, 0)
+	tree.Walk(func(n *CommandNode) {
+		names = append(names, n.Name())
+	})
+	assert.Equal(t, []string{"Inspect", "admin", "admin ban", "admin roles", "admin roles add", "ping"}, names)
+	assert.Len(t, tree.Leaves(), 4)
+
+	add := tree.Find("admin", "roles", "add")
+	assert.NotNil(t, add)
+	assert.Equal(t, []string{"admin", "roles", "add"}, add.Path)
+	assert.Equal(t, int64(discordgo.PermissionBanMembers), *add.DefaultPermissions)
+	assert.Equal(t, "/admin ban <user>", tree.Find("admin", "ban").Usage())
+	assert.Equal(t, discordgo.UserApplicationCommand, tree.Find("Inspect").Type)
+	assert.True(t, tree.Find("admin").IsGroup())
+	assert.Nil(t, tree.Find("admin", "kick"))
+}
+
+func TestCommandNodeAllowed(t *testing.T) {
+	harm := testCommandTree()
+	tree := harm.CommandTree()
+
+	member := &Invocation{Interaction: &discordgo.Interaction{GuildID: "guild", Member: &discordgo.Member{Permissions: discordgo.PermissionBanMembers}}}
+	assert.True(t, tree.Find("admin", "ban").Allowed(harm, member))
+	assert.False(t, tree.Find("admin", "roles", "add").Allowed(harm, member))
+
+	member.Member.Roles = []string{"helper"}
+	assert.True(t, tree.Find("admin", "roles", "add").Allowed(harm, member))
+
+	member.Member.Permissions = 0
+	assert.False(t, tree.Find("admin", "ban").Allowed(harm, member))
+	assert.True(t, tree.Find("ping").Allowed(harm, member))
+
+	dm := &Invocation{Interaction: &discordgo.Interaction{User: &discordgo.User{ID: "user"}}}
+	assert.True(t, tree.Find("ping").Allowed(harm, dm))
+	assert.False(t, tree.Find("Inspect").Allowed(harm, dm))
+}
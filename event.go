@@ -0,0 +1,263 @@
+package harmonia
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Names of the events listeners can be added for, as they appear in logs, metrics and spans.
+const (
+	eventMemberJoin     = "member_join"
+	eventMemberLeave    = "member_leave"
+	eventMessageCreate  = "message_create"
+	eventReactionAdd    = "reaction_add"
+	eventReactionRemove = "reaction_remove"
+	eventGuildJoin      = "guild_join"
+	eventGuildLeave     = "guild_leave"
+)
+
+// A MemberJoin describes a member that joined a guild.
+type MemberJoin struct {
+	*discordgo.Member
+	Author *Author
+}
+
+// A MemberLeave describes a user that left a guild, or was kicked or banned from it.
+type MemberLeave struct {
+	*discordgo.Member
+	Author *Author
+}
+
+// A MessageCreate describes a message that was sent.
+type MessageCreate struct {
+	*discordgo.Message
+	Author *Author
+}
+
+// A ReactionAdd describes a reaction that was added to a message.
+type ReactionAdd struct {
+	*discordgo.MessageReaction
+	Author *Author
+}
+
+// A ReactionRemove describes a reaction that was removed from a message. Discord only sends the ID of the user that removed it,
+// so Author is only resolved further when the user is in the State cache.
+type ReactionRemove struct {
+	*discordgo.MessageReaction
+	Author *Author
+}
+
+// A GuildJoin describes a guild the bot joined.
+type GuildJoin struct {
+	*discordgo.Guild
+}
+
+// A GuildLeave describes a guild the bot left, or was removed from.
+type GuildLeave struct {
+	*discordgo.Guild
+}
+
+// listener is a handler for an event, together with the guards that have to pass before it runs.
+type listener struct {
+	guards  []Guard
+	handler func(h *Harmonia, i *Invocation, event interface{})
+}
+
+// OnMemberJoin adds a listener that is called when a member joins a guild, once all guards have passed. It returns a function that removes the listener.
+// Receiving these events requires the privileged Server Members intent, which Run enables when there is a listener for them.
+func (h *Harmonia) OnMemberJoin(handler func(h *Harmonia, e *MemberJoin), guards ...Guard) (remove func()) {
+	return h.addListener(eventMemberJoin, guards, func(h *Harmonia, i *Invocation, event interface{}) {
+		handler(h, &MemberJoin{Member: event.(*discordgo.GuildMemberAdd).Member, Author: i.eventAuthor()})
+	})
+}
+
+// OnMemberLeave adds a listener that is called when a member leaves a guild, once all guards have passed. It returns a function that removes the listener.
+// Receiving these events requires the privileged Server Members intent, which Run enables when there is a listener for them.
+func (h *Harmonia) OnMemberLeave(handler func(h *Harmonia, e *MemberLeave), guards ...Guard) (remove func()) {
+	return h.addListener(eventMemberLeave, guards, func(h *Harmonia, i *Invocation, event interface{}) {
+		handler(h, &MemberLeave{Member: event.(*discordgo.GuildMemberRemove).Member, Author: i.eventAuthor()})
+	})
+}
+
+// OnMessageCreate adds a listener that is called when a message is sent, once all guards have passed. It returns a function that removes the listener.
+// The content of messages is only received with the privileged Message Content intent.
+func (h *Harmonia) OnMessageCreate(handler func(h *Harmonia, e *MessageCreate), guards ...Guard) (remove func()) {
+	return h.addListener(eventMessageCreate, guards, func(h *Harmonia, i *Invocation, event interface{}) {
+		handler(h, &MessageCreate{Message: event.(*discordgo.MessageCreate).Message, Author: i.eventAuthor()})
+	})
+}
+
+// OnReactionAdd adds a listener that is called when a reaction is added to a message, once all guards have passed. It returns a function that removes the listener.
+func (h *Harmonia) OnReactionAdd(handler func(h *Harmonia, e *ReactionAdd), guards ...Guard) (remove func()) {
+	return h.addListener(eventReactionAdd, guards, func(h *Harmonia, i *Invocation, event interface{}) {
+		handler(h, &ReactionAdd{MessageReaction: event.(*discordgo.MessageReactionAdd).MessageReaction, Author: i.eventAuthor()})
+	})
+}
+
+// OnReactionRemove adds a listener that is called when a reaction is removed from a message, once all guards have passed. It returns a function that removes the listener.
+func (h *Harmonia) OnReactionRemove(handler func(h *Harmonia, e *ReactionRemove), guards ...Guard) (remove func()) {
+	return h.addListener(eventReactionRemove, guards, func(h *Harmonia, i *Invocation, event interface{}) {
+		handler(h, &ReactionRemove{MessageReaction: event.(*discordgo.MessageReactionRemove).MessageReaction, Author: i.eventAuthor()})
+	})
+}
+
+// OnGuildJoin adds a listener that is called when the bot joins a guild, once all guards have passed. It returns a function that removes the listener.
+// Discord also sends this event for every guild the bot is in when it connects.
+func (h *Harmonia) OnGuildJoin(handler func(h *Harmonia, e *GuildJoin), guards ...Guard) (remove func()) {
+	return h.addListener(eventGuildJoin, guards, func(h *Harmonia, i *Invocation, event interface{}) {
+		handler(h, &GuildJoin{Guild: event.(*discordgo.GuildCreate).Guild})
+	})
+}
+
+// OnGuildLeave adds a listener that is called when the bot leaves or is removed from a guild, once all guards have passed. It returns a function that removes the listener.
+// Guilds that become unavailable because of an outage are not left.
+func (h *Harmonia) OnGuildLeave(handler func(h *Harmonia, e *GuildLeave), guards ...Guard) (remove func()) {
+	return h.addListener(eventGuildLeave, guards, func(h *Harmonia, i *Invocation, event interface{}) {
+		handler(h, &GuildLeave{Guild: event.(*discordgo.GuildDelete).Guild})
+	})
+}
+
+func (h *Harmonia) addListener(event string, guards []Guard, handler func(h *Harmonia, i *Invocation, event interface{})) func() {
+	l := &listener{guards: guards, handler: handler}
+
+	h.listenersMu.Lock()
+	defer h.listenersMu.Unlock()
+	if h.listeners == nil {
+		h.listeners = make(map[string][]*listener)
+	}
+	h.listeners[event] = append(h.listeners[event], l)
+
+	return func() {
+		h.listenersMu.Lock()
+		defer h.listenersMu.Unlock()
+
+		// The slice is copied, so that dispatching can keep using the old one without holding the lock.
+		listeners := make([]*listener, 0, len(h.listeners[event]))
+		for _, other := range h.listeners[event] {
+			if other != l {
+				listeners = append(listeners, other)
+			}
+		}
+		h.listeners[event] = listeners
+	}
+}
+
+func (h *Harmonia) hasListeners(event string) bool {
+	h.listenersMu.RLock()
+	defer h.listenersMu.RUnlock()
+	return len(h.listeners[event]) > 0
+}
+
+// DispatchEvent handles a gateway event that is not an Interaction, calling the listeners that were added for it.
+// Run calls DispatchEvent for every event received from the gateway. Listeners run in their own goroutine, see Wait.
+// Events caused by the bot itself are ignored.
+//
+// Like a TextCommand, every listener gets an Invocation filled in from the event, so the same guards can be used.
+// When a guard fails, the listener is not called and the error is logged, as there is nothing to reply to. Panics are recovered and logged like they are for commands.
+func (h *Harmonia) DispatchEvent(event interface{}) {
+	switch e := event.(type) {
+	case *discordgo.GuildMemberAdd:
+		h.dispatchEvent(eventMemberJoin, e, e.GuildID, "", e.Member, e.User)
+	case *discordgo.GuildMemberRemove:
+		h.dispatchEvent(eventMemberLeave, e, e.GuildID, "", nil, e.User)
+	case *discordgo.MessageCreate:
+		h.dispatchEvent(eventMessageCreate, e, e.GuildID, e.ChannelID, e.Member, e.Author)
+	case *discordgo.MessageReactionAdd:
+		user := h.eventUser(e.GuildID, e.UserID)
+		h.dispatchReaction(eventReactionAdd, e.MessageReaction, true, e.Member, user)
+		h.dispatchEvent(eventReactionAdd, e, e.GuildID, e.ChannelID, e.Member, user)
+	case *discordgo.MessageReactionRemove:
+		user := h.eventUser(e.GuildID, e.UserID)
+		h.dispatchReaction(eventReactionRemove, e.MessageReaction, false, nil, user)
+		h.dispatchEvent(eventReactionRemove, e, e.GuildID, e.ChannelID, nil, user)
+	case *discordgo.GuildCreate:
+		h.dispatchEvent(eventGuildJoin, e, e.ID, "", nil, nil)
+	case *discordgo.GuildDelete:
+		if !e.Unavailable {
+			h.dispatchEvent(eventGuildLeave, e, e.ID, "", nil, nil)
+		}
+	}
+}
+
+// eventUser returns the user with the given ID from the members of the guild in the State cache, or a user with only the ID if it is not cached.
+func (h *Harmonia) eventUser(guildID, userID string) *discordgo.User {
+	if state := h.state(); state != nil && guildID != "" {
+		if member, err := state.Member(guildID, userID); err == nil && member.User != nil {
+			return member.User
+		}
+	}
+	return &discordgo.User{ID: userID}
+}
+
+func (h *Harmonia) dispatchEvent(name string, event interface{}, guildID, channelID string, member *discordgo.Member, user *discordgo.User) {
+	h.listenersMu.RLock()
+	listeners := h.listeners[name]
+	h.listenersMu.RUnlock()
+
+	if member != nil && member.User != nil {
+		user = member.User
+	}
+	if user != nil && user.ID != "" && user.ID == h.appID() {
+		return
+	}
+
+	for _, l := range listeners {
+		i := h.newEventInvocation(name, guildID, channelID, member, user)
+
+		// Permissions are only resolved when there are guards that might need them, as it can cost REST requests.
+		guards := l.guards
+		if len(guards) > 0 {
+			guards = append([]Guard{func(h *Harmonia, i *Invocation) error {
+				h.resolvePermissions(i)
+				return nil
+			}}, guards...)
+		}
+
+		h.logger().Debug("dispatching event", i.logFields("event", name)...)
+		h.startInvocationSpan(i, "harmonia.event", "event", name)
+		if h.EagerResolve {
+			i.resolve()
+		}
+		handler := l.handler
+		h.invoke(i, guards, func(h *Harmonia, i *Invocation) {
+			handler(h, i, event)
+		})
+	}
+}
+
+// newEventInvocation creates an Invocation from an event. An Interaction is filled in from the event, without an ID or token.
+func (h *Harmonia) newEventInvocation(name, guildID, channelID string, member *discordgo.Member, user *discordgo.User) *Invocation {
+	interaction := &discordgo.Interaction{
+		AppID:     h.appID(),
+		GuildID:   guildID,
+		ChannelID: channelID,
+	}
+
+	if member != nil && guildID != "" {
+		m := *member
+		m.User = user
+		m.GuildID = guildID
+		interaction.Member = &m
+	} else {
+		interaction.User = user
+	}
+
+	return &Invocation{Interaction: interaction, h: h, event: name, dispatched: time.Now()}
+}
+
+// eventAuthor returns the Author of the event of the Invocation, or nil if it has none.
+// When the Author can not be resolved further, it is created from the user only.
+func (i *Invocation) eventAuthor() *Author {
+	user := i.invoker()
+	if user == nil {
+		return nil
+	}
+
+	author, err := i.GetAuthor()
+	if err != nil {
+		i.h.logger().Warn("could not resolve author", i.logFields("event", i.event, "error", err)...)
+		return AuthorFromUser(user)
+	}
+	return author
+}
@@ -37,6 +37,11 @@ func main() {
 		WithDescription("Increase or decrease the internal number!").
 		WithGuildID(*GuildID).
 		WithCommand(func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+			author, err := i.GetAuthor()
+			if err != nil {
+				log.Fatal(err)
+			}
+
 			msg, err := h.RespondWithComponents(i, fmt.Sprintf("The current number is %v!", number), [][]discordgo.MessageComponent{
 				{
 					discordgo.Button{
@@ -62,7 +67,7 @@ func main() {
 			}
 
 			h.AddComponentHandlerToInteractionMessage(msg, "n_increase", func(h *harmonia.Harmonia, ci *harmonia.Invocation) {
-				if i.Author.ID == ci.Author.ID {
+				if clicker, err := ci.GetAuthor(); err == nil && clicker.ID == author.ID {
 					number++
 					h.EphemeralRespond(ci, fmt.Sprintf("The number has been increased to %v", number))
 				} else {
@@ -70,7 +75,7 @@ func main() {
 				}
 			})
 			h.AddComponentHandlerToInteractionMessage(msg, "n_decrease", func(h *harmonia.Harmonia, ci *harmonia.Invocation) {
-				if i.Author.ID == ci.Author.ID {
+				if clicker, err := ci.GetAuthor(); err == nil && clicker.ID == author.ID {
 					number--
 					h.EphemeralRespond(ci, fmt.Sprintf("The number has been decreased to %v", number))
 				} else {
@@ -78,7 +83,7 @@ func main() {
 				}
 			})
 			h.AddComponentHandlerToInteractionMessage(msg, "n_reset", func(h *harmonia.Harmonia, ci *harmonia.Invocation) {
-				if i.Author.ID == ci.Author.ID {
+				if clicker, err := ci.GetAuthor(); err == nil && clicker.ID == author.ID {
 					number = 0
 					h.EphemeralRespond(ci, fmt.Sprintf("The number has been reset to %v", number))
 				} else {
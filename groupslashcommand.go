@@ -1,7 +1,9 @@
 package harmonia
 
 import (
+	"fmt"
 	"log"
+	"sort"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -13,28 +15,32 @@ type GroupSlashCommand struct {
 	guildID            string
 	dmPermission       bool
 	defaultPermissions *int64
+	guards             []Guard
 
 	subcommands map[string]CommandHandler
+	// errs are the problems found when adding subcommands, which are reported by validate.
+	errs []error
 
 	registration *discordgo.ApplicationCommand
 }
 
-// NewGroupSlashCommand returns a GroupSlashCommand with a given name
+// NewGroupSlashCommand returns a GroupSlashCommand with a given name.
+// The name is validated when the GroupSlashCommand is added with AddCommand, see MustNewGroupSlashCommand to validate it right away.
 func NewGroupSlashCommand(name string) *GroupSlashCommand {
-	if name == "" {
-		log.Panic("empty command name")
-	}
-
-	if !slashCommandNameRegex.MatchString(name) {
-		log.Panic("slash command name does not match with the CHAT_INPUT regex.")
-	}
-
 	return &GroupSlashCommand{
 		name:        name,
 		subcommands: make(map[string]CommandHandler),
 	}
 }
 
+// MustNewGroupSlashCommand does the same as NewGroupSlashCommand, but panics if the name is not valid.
+func MustNewGroupSlashCommand(name string) *GroupSlashCommand {
+	if err := validateChatName("command", name); err != nil {
+		log.Panic(err)
+	}
+	return NewGroupSlashCommand(name)
+}
+
 // WithDescription changes the description of the GroupSlashCommand and returns itself, so that it can be chained.
 func (s *GroupSlashCommand) WithDescription(description string) *GroupSlashCommand {
 	s.description = description
@@ -47,18 +53,22 @@ func (s *GroupSlashCommand) WithGuildID(guildID string) *GroupSlashCommand {
 	return s
 }
 
+// WithSubCommands adds SlashCommands and GroupSlashCommands as subcommands and returns itself, so that it can be chained.
+// Subcommands that are of another type or have the name of an existing subcommand are not added, they are reported when the GroupSlashCommand is validated.
 func (s *GroupSlashCommand) WithSubCommands(subcommands ...CommandHandler) *GroupSlashCommand {
 	for _, command := range subcommands {
 		name := command.GetName()
 
-		if _, ok := command.(*SlashCommand); !ok {
-			if _, ok := command.(*GroupSlashCommand); !ok {
-				log.Panic("supplied subcommand is neither SlashCommand nor GroupSlashCommand")
-			}
+		switch command.(type) {
+		case *SlashCommand, *GroupSlashCommand:
+		default:
+			s.errs = append(s.errs, fmt.Errorf("subcommand '%v' of group '%v' is neither a SlashCommand nor a GroupSlashCommand", name, s.name))
+			continue
 		}
 
 		if _, ok := s.subcommands[name]; ok {
-			log.Panic("duplicate subcommand name")
+			s.errs = append(s.errs, fmt.Errorf("group '%v' has more than one subcommand named '%v'", s.name, name))
+			continue
 		}
 
 		s.subcommands[name] = command
@@ -78,23 +88,92 @@ func (s *GroupSlashCommand) WithDefaultPermissions(defaultPermissions int64) *Gr
 	return s
 }
 
+// WithGuards adds guards that have to pass before the GroupSlashCommand is executed and returns itself, so that it can be chained.
+func (s *GroupSlashCommand) WithGuards(guards ...Guard) *GroupSlashCommand {
+	s.guards = append(s.guards, guards...)
+	return s
+}
+
+// WithBotPermissions makes the GroupSlashCommand check if the bot has the given permissions before it is executed and returns itself, so that it can be chained.
+func (s *GroupSlashCommand) WithBotPermissions(perms int64) *GroupSlashCommand {
+	return s.WithGuards(RequireBotPermissions(perms))
+}
+
 func (s *GroupSlashCommand) GetName() string {
 	return s.name
 }
 
 func (s *GroupSlashCommand) Do(h *Harmonia, i *Invocation) {
 	options := i.options
-	if command, ok := s.subcommands[options[0].Name]; ok {
-		i.options = options[0].Options
-		go command.Do(h, i)
+	if len(options) == 0 {
+		h.replyError(i, fmt.Sprintf("missing subcommand of '%v'", s.name))
+		return
 	}
+
+	command, ok := s.subcommands[options[0].Name]
+	if !ok {
+		h.replyError(i, fmt.Sprintf("unknown subcommand '%v' of '%v'", options[0].Name, s.name))
+		return
+	}
+
+	// The guards of the group are run together with those of the subcommand, right before its handler.
+	i.options = options[0].Options
+	i.guards = append(i.guards, s.guards...)
+	command.Do(h, i)
+}
+
+// maxSubcommands is the maximum number of subcommands Discord allows in a group.
+const maxSubcommands = 25
+
+// validate checks the GroupSlashCommand and its subcommands against the limits of Discord.
+// A nested GroupSlashCommand can only contain SlashCommands, as Discord does not allow groups deeper than group, subcommand group, subcommand.
+func (s *GroupSlashCommand) validate(nested bool) []error {
+	errs := append(make([]error, 0), s.errs...)
+	if err := validateDescription(s.name, s.description); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(s.subcommands) == 0 {
+		errs = append(errs, fmt.Errorf("group '%v' has no subcommands", s.name))
+	}
+
+	if len(s.subcommands) > maxSubcommands {
+		errs = append(errs, fmt.Errorf("group '%v' has %v subcommands, at most %v are allowed", s.name, len(s.subcommands), maxSubcommands))
+	}
+
+	names := make([]string, 0, len(s.subcommands))
+	for name := range s.subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if validateChatName("subcommand", name) != nil {
+			errs = append(errs, fmt.Errorf("subcommand name '%v' of group '%v' is not a valid lowercase slash command name", name, s.name))
+		}
+
+		switch command := s.subcommands[name].(type) {
+		case *GroupSlashCommand:
+			if nested {
+				errs = append(errs, fmt.Errorf("group '%v' of group '%v' is nested too deep, a subcommand group can only contain subcommands", name, s.name))
+				continue
+			}
+			errs = append(errs, command.validate(true)...)
+		case *SlashCommand:
+			errs = append(errs, command.validate()...)
+		}
+	}
+	return errs
 }
 
 func (s *GroupSlashCommand) getRegistration() *discordgo.ApplicationCommand {
 	if s.registration != nil {
 		return s.registration
 	}
+	return s.buildRegistration()
+}
 
+func (s *GroupSlashCommand) buildRegistration() *discordgo.ApplicationCommand {
 	options := make([]*discordgo.ApplicationCommandOption, len(s.subcommands))
 	i := 0
 	for _, command := range s.subcommands {
@@ -103,7 +182,7 @@ func (s *GroupSlashCommand) getRegistration() *discordgo.ApplicationCommand {
 			t = discordgo.ApplicationCommandOptionSubCommandGroup
 		}
 
-		data := command.getRegistration()
+		data := command.buildRegistration()
 
 		options[i] = &discordgo.ApplicationCommandOption{
 			Name:        data.Name,
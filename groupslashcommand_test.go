@@ -0,0 +1,58 @@
+package harmonia
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupSlashCommandValidate(t *testing.T) {
+	harm := &Harmonia{Commands: make(map[string]CommandHandler)}
+
+	assert.EqualError(t, harm.AddCommand(NewGroupSlashCommand("empty").WithDescription("Nothing")), "group 'empty' has no subcommands")
+
+	deep := NewGroupSlashCommand("a").WithDescription("A").WithSubCommands(
+		NewGroupSlashCommand("b").WithDescription("B").WithSubCommands(
+			NewGroupSlashCommand("c").WithDescription("C").WithSubCommands(NewSlashCommand("d").WithDescription("D")),
+		),
+	)
+	assert.EqualError(t, harm.AddCommand(deep), "group 'c' of group 'b' is nested too deep, a subcommand group can only contain subcommands")
+
+	large := NewGroupSlashCommand("large").WithDescription("Large")
+	for n := 0; n < 26; n++ {
+		large.WithSubCommands(NewSlashCommand(fmt.Sprintf("sub%v", n)).WithDescription("Sub"))
+	}
+	assert.EqualError(t, harm.AddCommand(large), "group 'large' has 26 subcommands, at most 25 are allowed")
+
+	upper := NewGroupSlashCommand("upper").WithDescription("Upper").WithSubCommands(NewSlashCommand("Ban").WithDescription("Bans"))
+	assert.EqualError(t, harm.AddCommand(upper), "subcommand name 'Ban' of group 'upper' is not a valid lowercase slash command name")
+
+	mixed := NewGroupSlashCommand("mixed").WithDescription("Mixed").WithSubCommands(
+		NewSlashCommand("ban").WithDescription("Bans").WithOptions(NewOption("kick", discordgo.ApplicationCommandOptionSubCommand)),
+	)
+	assert.EqualError(t, harm.AddCommand(mixed), "option 'kick' of 'ban' is a subcommand, use a GroupSlashCommand instead")
+
+	assert.Len(t, harm.Commands, 0)
+	assert.Nil(t, harm.AddCommand(NewGroupSlashCommand("admin").WithDescription("Moderation").WithSubCommands(
+		NewGroupSlashCommand("roles").WithDescription("Manages roles").WithSubCommands(NewSlashCommand("add").WithDescription("Adds a role")),
+		NewSlashCommand("ban").WithDescription("Bans a user"),
+	)))
+}
+
+func TestGroupSlashCommandUnexpectedOptions(t *testing.T) {
+	rest := &recordingREST{}
+	harm := &Harmonia{REST: rest, Commands: make(map[string]CommandHandler)}
+	harm.AddCommand(NewGroupSlashCommand("admin").WithDescription("Moderation").WithSubCommands(NewSlashCommand("ban").WithDescription("Bans a user")))
+
+	harm.Dispatch(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type: discordgo.InteractionApplicationCommand,
+		Data: discordgo.ApplicationCommandInteractionData{Name: "admin"},
+		User: &discordgo.User{ID: "user"},
+	}})
+	harm.Wait()
+
+	assert.Len(t, rest.responses, 1)
+	assert.Equal(t, "missing subcommand of 'admin'", rest.responses[0].Data.Content)
+}
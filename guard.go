@@ -0,0 +1,163 @@
+package harmonia
+
+import (
+	"errors"
+	"fmt"
+)
+
+// A Guard is a check that is evaluated against an Invocation before the handler of a command runs.
+// If a Guard returns an error, the handler is not run and the error is shown to the invoker.
+type Guard func(h *Harmonia, i *Invocation) error
+
+var (
+	// ErrGuildOnly is returned by GuildOnly when the Invocation happened outside of a guild.
+	ErrGuildOnly = errors.New("this command can only be used in a server")
+	// ErrDMOnly is returned by DMOnly when the Invocation happened inside of a guild.
+	ErrDMOnly = errors.New("this command can only be used in DMs")
+	// ErrNotOwner is returned by RequireOwner when the invoker does not own the guild.
+	ErrNotOwner = errors.New("only the owner of this server can use this command")
+	// ErrNotNSFWChannel is returned by RequireNSFWChannel when the Invocation happened outside of an NSFW channel.
+	ErrNotNSFWChannel = errors.New("this command can only be used in age-restricted channels")
+)
+
+// And returns a Guard that passes when all of the given guards pass. The error of the first failing guard is returned.
+func And(guards ...Guard) Guard {
+	return func(h *Harmonia, i *Invocation) error {
+		for _, guard := range guards {
+			if err := guard(h, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Or returns a Guard that passes when any of the given guards pass. If all guards fail, the error of the first guard is returned.
+func Or(guards ...Guard) Guard {
+	return func(h *Harmonia, i *Invocation) error {
+		var first error
+		for _, guard := range guards {
+			err := guard(h, i)
+			if err == nil {
+				return nil
+			}
+			if first == nil {
+				first = err
+			}
+		}
+		return first
+	}
+}
+
+// GuildOnly is a Guard that only passes when the Invocation happened inside of a guild.
+func GuildOnly(h *Harmonia, i *Invocation) error {
+	if i.GuildID == "" {
+		return ErrGuildOnly
+	}
+	return nil
+}
+
+// DMOnly is a Guard that only passes when the Invocation happened in DMs.
+func DMOnly(h *Harmonia, i *Invocation) error {
+	if i.GuildID != "" {
+		return ErrDMOnly
+	}
+	return nil
+}
+
+// RequireOwner is a Guard that only passes when the invoker is the owner of the guild the Invocation happened in.
+func RequireOwner(h *Harmonia, i *Invocation) error {
+	if i.GuildID == "" {
+		return ErrNotOwner
+	}
+
+	guild, err := i.GetGuild()
+	if err != nil {
+		return err
+	}
+
+	author, err := i.GetAuthor()
+	if err != nil {
+		return err
+	}
+
+	if guild.OwnerID != author.ID {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+// RequireNSFWChannel is a Guard that only passes when the Invocation happened in an age-restricted channel.
+func RequireNSFWChannel(h *Harmonia, i *Invocation) error {
+	channel, err := i.GetChannel()
+	if err != nil {
+		return err
+	}
+
+	if !channel.NSFW {
+		return ErrNotNSFWChannel
+	}
+	return nil
+}
+
+// RequirePermissions returns a Guard that only passes when the invoker has all of the given permissions in the channel of the Invocation.
+// The permissions are taken from the Interaction, so channel overwrites are already taken into account.
+func RequirePermissions(perms int64) Guard {
+	return func(h *Harmonia, i *Invocation) error {
+		if i.Member == nil {
+			return ErrGuildOnly
+		}
+
+		if missing := MissingPermissions(i.Member.Permissions, perms); missing != 0 {
+			return fmt.Errorf("you are missing the following permissions: %v", formatPermissions(missing))
+		}
+		return nil
+	}
+}
+
+// RequireRoles returns a Guard that only passes when the invoker has all of the roles with the given IDs.
+// The role IDs are taken from the Interaction, so no requests have to be made.
+// Use Or to require any one of a set of roles.
+func RequireRoles(roleIDs ...string) Guard {
+	return func(h *Harmonia, i *Invocation) error {
+		if i.Member == nil {
+			return ErrGuildOnly
+		}
+
+		roles := make(map[string]bool, len(i.Member.Roles))
+		for _, roleID := range i.Member.Roles {
+			roles[roleID] = true
+		}
+
+		for _, roleID := range roleIDs {
+			if !roles[roleID] {
+				return errors.New("you do not have the roles required to use this command")
+			}
+		}
+		return nil
+	}
+}
+
+// RequireBotPermissions returns a Guard that only passes when the bot has all of the given permissions in the channel of the Invocation.
+// The invoker is told which permissions are missing, instead of the handler failing halfway through.
+func RequireBotPermissions(perms int64) Guard {
+	return func(h *Harmonia, i *Invocation) error {
+		if missing := MissingPermissions(i.AppPermissions, perms); missing != 0 {
+			return fmt.Errorf("I am missing the following permissions to do this: %v", formatPermissions(missing))
+		}
+		return nil
+	}
+}
+
+// checkGuards evaluates the guards against the Invocation and tells the invoker why the first failing guard failed.
+func (h *Harmonia) checkGuards(i *Invocation, guards []Guard) bool {
+	if err := And(guards...)(h, i); err != nil {
+		h.logger().Debug("guard rejected invocation", i.logFields("error", err)...)
+		if i.span != nil {
+			i.span.SetAttributes("guard_error", err.Error())
+		}
+		h.replyError(i, err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package harmonia
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func guildInvocation(perms int64, roles ...*discordgo.Role) *Invocation {
+	user := &discordgo.User{ID: "user"}
+	roleIDs := make([]string, len(roles))
+	for i, role := range roles {
+		roleIDs[i] = role.ID
+	}
+
+	return &Invocation{
+		Interaction: &discordgo.Interaction{
+			GuildID: "guild",
+			Member:  &discordgo.Member{User: user, Permissions: perms, Roles: roleIDs},
+		},
+		Guild:   &discordgo.Guild{ID: "guild", OwnerID: "owner"},
+		Channel: &discordgo.Channel{ID: "channel"},
+		Author:  &Author{User: user, IsMember: true, Roles: roles},
+	}
+}
+
+func dmInvocation() *Invocation {
+	user := &discordgo.User{ID: "user"}
+	return &Invocation{
+		Interaction: &discordgo.Interaction{User: user},
+		Author:      AuthorFromUser(user),
+	}
+}
+
+func TestLocationGuards(t *testing.T) {
+	assert.Nil(t, GuildOnly(nil, guildInvocation(0)))
+	assert.Equal(t, ErrGuildOnly, GuildOnly(nil, dmInvocation()))
+
+	assert.Nil(t, DMOnly(nil, dmInvocation()))
+	assert.Equal(t, ErrDMOnly, DMOnly(nil, guildInvocation(0)))
+
+	i := guildInvocation(0)
+	assert.Equal(t, ErrNotNSFWChannel, RequireNSFWChannel(nil, i))
+	i.Channel.NSFW = true
+	assert.Nil(t, RequireNSFWChannel(nil, i))
+}
+
+func TestRequireOwner(t *testing.T) {
+	i := guildInvocation(0)
+	assert.Equal(t, ErrNotOwner, RequireOwner(nil, i))
+	assert.Equal(t, ErrNotOwner, RequireOwner(nil, dmInvocation()))
+
+	i.Guild.OwnerID = "user"
+	assert.Nil(t, RequireOwner(nil, i))
+}
+
+func TestRequirePermissions(t *testing.T) {
+	guard := RequirePermissions(discordgo.PermissionBanMembers | discordgo.PermissionKickMembers)
+
+	assert.Nil(t, guard(nil, guildInvocation(discordgo.PermissionBanMembers|discordgo.PermissionKickMembers)))
+	assert.Nil(t, guard(nil, guildInvocation(discordgo.PermissionAdministrator)))
+	assert.EqualError(t, guard(nil, guildInvocation(discordgo.PermissionKickMembers)), "you are missing the following permissions: Ban Members")
+	assert.Equal(t, ErrGuildOnly, guard(nil, dmInvocation()))
+}
+
+func TestRequireRoles(t *testing.T) {
+	moderator := &discordgo.Role{ID: "moderator"}
+	admin := &discordgo.Role{ID: "admin"}
+
+	assert.Nil(t, RequireRoles("moderator")(nil, guildInvocation(0, moderator)))
+	assert.Nil(t, RequireRoles("moderator", "admin")(nil, guildInvocation(0, moderator, admin)))
+	assert.NotNil(t, RequireRoles("moderator", "admin")(nil, guildInvocation(0, moderator)))
+	assert.Equal(t, ErrGuildOnly, RequireRoles("moderator")(nil, dmInvocation()))
+}
+
+func TestComposedGuards(t *testing.T) {
+	moderator := &discordgo.Role{ID: "moderator"}
+
+	either := Or(RequireRoles("admin"), RequireRoles("moderator"))
+	assert.Nil(t, either(nil, guildInvocation(0, moderator)))
+	assert.NotNil(t, either(nil, guildInvocation(0)))
+
+	both := And(GuildOnly, RequireRoles("moderator"))
+	assert.Nil(t, both(nil, guildInvocation(0, moderator)))
+	assert.Equal(t, ErrGuildOnly, both(nil, dmInvocation()))
+
+	assert.Nil(t, And()(nil, dmInvocation()))
+	assert.Nil(t, Or()(nil, dmInvocation()))
+}
+
+func TestRequireBotPermissions(t *testing.T) {
+	guard := RequireBotPermissions(discordgo.PermissionSendMessages | discordgo.PermissionEmbedLinks | discordgo.PermissionManageRoles)
+
+	i := guildInvocation(0)
+	i.AppPermissions = discordgo.PermissionSendMessages
+	assert.EqualError(t, guard(nil, i), "I am missing the following permissions to do this: Manage Roles, Embed Links")
+
+	i.AppPermissions |= discordgo.PermissionEmbedLinks | discordgo.PermissionManageRoles
+	assert.Nil(t, guard(nil, i))
+
+	i.AppPermissions = discordgo.PermissionAdministrator
+	assert.Nil(t, guard(nil, i))
+}
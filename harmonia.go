@@ -1,8 +1,11 @@
 package harmonia
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -13,8 +16,60 @@ const VERSION = "0.7.4"
 // A Harmonia represents a connection to the Discord API and contains the slash commands and component handlers used by Harmonia.
 type Harmonia struct {
 	*discordgo.Session
+
+	// REST is used for all calls Harmonia makes to the Discord REST API. When it is nil, the Session is used.
+	REST RESTClient
+
+	// ApplicationID is the ID of the application the commands are registered to.
+	// When it is empty, the ID of the user the Session is logged in as is used, which RegisterCommands fills in if needed.
+	ApplicationID string
+
+	// Commands, ComponentHandlers and TextCommands are read while dispatching, so once Harmonia is running
+	// they should only be changed through the methods that add and remove them.
 	Commands          map[string]CommandHandler
 	ComponentHandlers map[string]CommandFunc
+	TextCommands      map[string]*TextCommand
+
+	// Prefix returns the prefixes TextCommands can be invoked with. TextCommands are not handled when it is nil.
+	// When it is set, Run requests the privileged Message Content intent, which has to be enabled for the bot.
+	Prefix PrefixFunc
+
+	// EagerResolve makes Harmonia populate the Guild, Channel and Author fields of every Invocation before its handler runs.
+	// This is off by default, as resolving them can cost REST requests that eat into the time available to respond.
+	EagerResolve bool
+
+	// Logger receives structured events about dispatching, registration and errors. Nothing is logged when it is nil.
+	// A *slog.Logger can be used directly, see Logger.
+	Logger Logger
+
+	// Metrics receives measurements about the commands and components that are handled. Nothing is measured when it is nil.
+	Metrics Metrics
+
+	// OnUnknownCommand handles an Invocation of a command that is not in Commands, such as one that was removed but is still registered.
+	// When it is nil, DefaultUnknownCommand is used.
+	OnUnknownCommand CommandFunc
+	// OnUnknownComponent handles an Invocation of a component that has no handler in ComponentHandlers, such as a button on a message from before a restart.
+	// When it is nil, DefaultUnknownComponent is used.
+	OnUnknownComponent CommandFunc
+
+	// Tracer starts a span for every Invocation that is dispatched, see Tracer. Nothing is traced when it is nil.
+	Tracer Tracer
+
+	// handlersMu guards Commands, ComponentHandlers, TextCommands and messageHandlers, as well as the registrations of the commands.
+	handlersMu sync.RWMutex
+	// messageHandlers are the keys in ComponentHandlers of handlers added to InteractionMessages.
+	messageHandlers map[string]bool
+
+	listenersMu sync.RWMutex
+	listeners   map[string][]*listener
+	reactions   reactions
+
+	modulesMu sync.Mutex
+	modules   map[string]*loadedModule
+	// registered is set once RegisterCommands has run, after which commands of modules are registered when they are loaded.
+	registered bool
+
+	running handlerTracker
 }
 
 // New creates a new Discord session with the provided token and wraps the Harmonia struct around it.
@@ -26,15 +81,24 @@ func New(token string) (h *Harmonia, err error) {
 
 	h = &Harmonia{
 		Session:           s,
+		REST:              s,
 		Commands:          make(map[string]CommandHandler),
 		ComponentHandlers: make(map[string]CommandFunc),
+		TextCommands:      make(map[string]*TextCommand),
 	}
 
 	return h, err
 }
 
-// AddCommand adds a command to Harmonia.
+// AddCommand adds a command to Harmonia. The command is validated against the limits of Discord first, returning a ValidationError with every problem that was found.
 func (h *Harmonia) AddCommand(command CommandHandler) (err error) {
+	if err := newValidationError(validateCommand(command)); err != nil {
+		return err
+	}
+
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+
 	name := command.GetName()
 	if _, ok := h.Commands[name]; ok {
 		return fmt.Errorf("command '%v' already exists", name)
@@ -44,27 +108,92 @@ func (h *Harmonia) AddCommand(command CommandHandler) (err error) {
 	return
 }
 
+// command returns the command with the given name.
+func (h *Harmonia) command(name string) (CommandHandler, bool) {
+	h.handlersMu.RLock()
+	defer h.handlersMu.RUnlock()
+	command, ok := h.Commands[name]
+	return command, ok
+}
+
+// componentHandler returns the handler for a component with the given CustomID, looking for a handler added to the message with the given ID if there is no global one.
+func (h *Harmonia) componentHandler(customID, messageID string) (CommandFunc, bool) {
+	h.handlersMu.RLock()
+	defer h.handlersMu.RUnlock()
+	if handler, ok := h.ComponentHandlers[customID]; ok {
+		return handler, true
+	}
+	handler, ok := h.ComponentHandlers[fmt.Sprintf("%v-%v", messageID, customID)]
+	return handler, ok
+}
+
+// state returns the State cache of the Session, or nil if there is no Session.
+func (h *Harmonia) state() *discordgo.State {
+	if h.Session == nil {
+		return nil
+	}
+	return h.State
+}
+
+// guild returns the guild with the given ID from the State cache, only requesting it from the Discord API when it is not cached.
+func (h *Harmonia) guild(guildID string) (*discordgo.Guild, error) {
+	if guildID == "" {
+		return nil, discordgo.ErrStateNotFound
+	}
+	if guild, err := h.state().Guild(guildID); err == nil {
+		return guild, nil
+	}
+	return h.RESTClient().Guild(guildID)
+}
+
+// channel returns the channel with the given ID from the State cache, only requesting it from the Discord API when it is not cached.
+func (h *Harmonia) channel(channelID string) (*discordgo.Channel, error) {
+	if channelID == "" {
+		return nil, discordgo.ErrStateNotFound
+	}
+	if channel, err := h.state().Channel(channelID); err == nil {
+		return channel, nil
+	}
+	return h.RESTClient().Channel(channelID)
+}
+
 func (h *Harmonia) interactionMessageFromMessage(m *discordgo.Message, i *discordgo.Interaction) *InteractionMessage {
 	f := &InteractionMessage{Message: m, Interaction: i}
 
 	if m != nil {
-		guild, _ := h.Guild(m.GuildID)
-		f.Guild = guild
-
-		channel, _ := h.Channel(m.ChannelID)
-		f.Channel = channel
+		var err error
+		if m.GuildID != "" {
+			if f.Guild, err = h.guild(m.GuildID); err != nil {
+				h.logger().Warn("could not resolve guild of message", "message_id", m.ID, "guild_id", m.GuildID, "error", err)
+			}
+		}
+		if f.Channel, err = h.channel(m.ChannelID); err != nil {
+			h.logger().Warn("could not resolve channel of message", "message_id", m.ID, "channel_id", m.ChannelID, "error", err)
+		}
 	}
 
 	return f
 }
 
 // RespondComplex allows you full freedom to respond with whatever you'd like.
-func (h *Harmonia) RespondComplex(i *Invocation, resp *discordgo.InteractionResponse) (*InteractionMessage, error) {
-	err := h.InteractionRespond(i.Interaction, resp)
+// When the Invocation comes from a message, the response is sent as a reply to it instead, see TextCommand.
+// A deferred response to a message sends nothing yet, so it returns a nil InteractionMessage.
+func (h *Harmonia) RespondComplex(i *Invocation, resp *discordgo.InteractionResponse) (f *InteractionMessage, err error) {
+	span := i.startSpan("harmonia.respond")
+	defer func() { endSpan(span, err) }()
+
+	if i.TextMessage != nil {
+		f, err := h.textRespond(i, resp)
+		h.observeResponse(i, err)
+		return f, err
+	}
+
+	err = h.RESTClient().InteractionRespond(i.Interaction, resp)
+	h.observeResponse(i, err)
 	if err != nil {
 		return nil, err
 	}
-	m, err := h.InteractionResponse(i.Interaction)
+	m, err := h.RESTClient().InteractionResponse(i.Interaction)
 	return h.interactionMessageFromMessage(m, i.Interaction), err
 }
 
@@ -102,38 +231,86 @@ func (h *Harmonia) RespondWithComponents(i *Invocation, content string, componen
 }
 
 // DeferResponse sends an acknowledgement to the DiscordAPI, allowing you to send a follow-up message later. See Followup for that.
-func (h *Harmonia) DeferResponse(i *Invocation) error {
-	return h.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+// When the Invocation comes from a message, a typing indicator is shown instead.
+func (h *Harmonia) DeferResponse(i *Invocation) (err error) {
+	span := i.startSpan("harmonia.defer_response")
+	defer func() {
+		h.observeResponse(i, err)
+		endSpan(span, err)
+	}()
+
+	if i.TextMessage != nil {
+		return h.RESTClient().ChannelTyping(i.ChannelID)
+	}
+
+	return h.RESTClient().InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 	})
 }
 
 // EditResponse edits an already sent response.
 func (h *Harmonia) EditResponse(i *Invocation, content string) (*InteractionMessage, error) {
-	m, err := h.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+	return h.editResponse(i, &discordgo.WebhookEdit{
 		Content: &content,
 	})
-	return h.interactionMessageFromMessage(m, i.Interaction), err
 }
 
 // EditResponseWithComponents does the same as EditResponse, but also takes in a 2D slice of discordgo.MessageComponents that will be added to the response.
 func (h *Harmonia) EditResponseWithComponents(i *Invocation, content string, components [][]discordgo.MessageComponent) (*InteractionMessage, error) {
 	comp := ParseComponentMatrix(components)
-	m, err := h.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+	return h.editResponse(i, &discordgo.WebhookEdit{
 		Content:    &content,
 		Components: &comp,
 	})
+}
+
+func (h *Harmonia) editResponse(i *Invocation, edit *discordgo.WebhookEdit) (f *InteractionMessage, err error) {
+	span := i.startSpan("harmonia.edit_response")
+	defer func() { endSpan(span, err) }()
+
+	if i.TextMessage != nil {
+		f, err := h.textEditResponse(i, edit)
+		h.observeError(i, err)
+		return f, err
+	}
+
+	m, err := h.RESTClient().InteractionResponseEdit(i.Interaction, edit)
+	h.observeError(i, err)
 	return h.interactionMessageFromMessage(m, i.Interaction), err
 }
 
 // DeleteResponse deletes a response.
-func (h *Harmonia) DeleteResponse(i *Invocation) error {
-	return h.InteractionResponseDelete(i.Interaction)
+func (h *Harmonia) DeleteResponse(i *Invocation) (err error) {
+	span := i.startSpan("harmonia.delete_response")
+	defer func() {
+		h.observeError(i, err)
+		endSpan(span, err)
+	}()
+
+	if i.TextMessage != nil {
+		if i.response == nil {
+			return errors.New("there is no response to delete")
+		}
+		return h.RESTClient().ChannelMessageDelete(i.response.ChannelID, i.response.ID)
+	}
+
+	return h.RESTClient().InteractionResponseDelete(i.Interaction)
 }
 
 // FollowupComplex allows you full freedom to follow-up with whatever you'd like.
-func (h *Harmonia) FollowupComplex(i *Invocation, params *discordgo.WebhookParams) (*InteractionMessage, error) {
-	m, err := h.FollowupMessageCreate(i.Interaction, true, params)
+// When the Invocation comes from a message, the follow-up message is sent as another reply to it.
+func (h *Harmonia) FollowupComplex(i *Invocation, params *discordgo.WebhookParams) (f *InteractionMessage, err error) {
+	span := i.startSpan("harmonia.followup")
+	defer func() { endSpan(span, err) }()
+
+	if i.TextMessage != nil {
+		f, err := h.textFollowup(i, params)
+		h.observeError(i, err)
+		return f, err
+	}
+
+	m, err := h.RESTClient().FollowupMessageCreate(i.Interaction, true, params)
+	h.observeError(i, err)
 	return h.interactionMessageFromMessage(m, i.Interaction), err
 }
 
@@ -163,25 +340,37 @@ func (h *Harmonia) FollowupWithComponents(i *Invocation, content string, compone
 
 // EditFollowup allows you to edit a follow-up message.
 func (h *Harmonia) EditFollowup(f *InteractionMessage, content string) (*InteractionMessage, error) {
-	m, err := h.FollowupMessageEdit(f.Interaction, f.ID, &discordgo.WebhookEdit{
+	return h.editFollowup(f, &discordgo.WebhookEdit{
 		Content: &content,
 	})
-	return h.interactionMessageFromMessage(m, f.Interaction), err
 }
 
 // EditFollowupWithComponents does the same as EditFollowup, but also takes in a 2D slice of discordgo.MessageComponents that will be added to the follow-up message.
 func (h *Harmonia) EditFollowupWithComponents(f *InteractionMessage, content string, components [][]discordgo.MessageComponent) (*InteractionMessage, error) {
 	comp := ParseComponentMatrix(components)
-	m, err := h.FollowupMessageEdit(f.Interaction, f.ID, &discordgo.WebhookEdit{
+	return h.editFollowup(f, &discordgo.WebhookEdit{
 		Content:    &content,
 		Components: &comp,
 	})
+}
+
+func (h *Harmonia) editFollowup(f *InteractionMessage, edit *discordgo.WebhookEdit) (*InteractionMessage, error) {
+	if f.text {
+		m, err := h.RESTClient().ChannelMessageEditComplex(messageEdit(f.ChannelID, f.ID, edit))
+		return h.textMessage(m, f.Interaction), err
+	}
+
+	m, err := h.RESTClient().FollowupMessageEdit(f.Interaction, f.ID, edit)
 	return h.interactionMessageFromMessage(m, f.Interaction), err
 }
 
 // DeleteFollowup deletes a follow-up message.
 func (h *Harmonia) DeleteFollowup(f *InteractionMessage) error {
-	return h.FollowupMessageDelete(f.Interaction, f.ID)
+	if f.text {
+		return h.RESTClient().ChannelMessageDelete(f.ChannelID, f.ID)
+	}
+
+	return h.RESTClient().FollowupMessageDelete(f.Interaction, f.ID)
 }
 
 // AddComponentHandler adds a handler for a component.
@@ -191,6 +380,9 @@ func (h *Harmonia) AddComponentHandler(customID string, handler CommandFunc) err
 		return errors.New("empty CustomID")
 	}
 
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+
 	if _, ok := h.ComponentHandlers[customID]; ok {
 		return fmt.Errorf("CustomID '%v' already exists", customID)
 	}
@@ -205,159 +397,509 @@ func (h *Harmonia) AddComponentHandlerToInteractionMessage(f *InteractionMessage
 	if customID == "" {
 		return errors.New("empty CustomID")
 	}
+	if err := checkInteractionMessage(f); err != nil {
+		return err
+	}
 
 	followupcustomID := fmt.Sprintf("%v-%v", f.ID, customID)
 
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+
 	if _, ok := h.ComponentHandlers[followupcustomID]; ok {
 		return fmt.Errorf("customID '%v' already exists on Followup '%v'", customID, f.ID)
 	}
 
 	h.ComponentHandlers[followupcustomID] = handler
+	if h.messageHandlers == nil {
+		h.messageHandlers = make(map[string]bool)
+	}
+	h.messageHandlers[followupcustomID] = true
+	h.metrics().MessageHandlers(len(h.messageHandlers))
 	return nil
 }
 
 // RemoveComponentHandler removes a component handler.
 func (h *Harmonia) RemoveComponentHandler(customID string) error {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+
 	if _, ok := h.ComponentHandlers[customID]; !ok {
 		return fmt.Errorf("customID '%v' not found", customID)
 	}
 	delete(h.ComponentHandlers, customID)
+	h.forgetMessageHandler(customID)
 	return nil
 }
 
 // RemoveComponentHandlerFromInteractionMessage removes a component handler from an InteractionMessage.
 func (h *Harmonia) RemoveComponentHandlerFromInteractionMessage(f *InteractionMessage, customID string) error {
+	if err := checkInteractionMessage(f); err != nil {
+		return err
+	}
+
 	followupcustomID := fmt.Sprintf("%v-%v", f.ID, customID)
+
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+
 	if _, ok := h.ComponentHandlers[followupcustomID]; !ok {
 		return fmt.Errorf("customID '%v' not found on Followup '%v'", customID, f.ID)
 	}
 	delete(h.ComponentHandlers, followupcustomID)
+	h.forgetMessageHandler(followupcustomID)
 	return nil
 }
 
+// ComponentHandlerIDs returns the keys of all component handlers that are added, sorted.
+// Handlers added to an InteractionMessage are keyed by the ID of the message and their CustomID, such as "123-confirm".
+func (h *Harmonia) ComponentHandlerIDs() []string {
+	h.handlersMu.RLock()
+	defer h.handlersMu.RUnlock()
+
+	ids := make([]string, 0, len(h.ComponentHandlers))
+	for id := range h.ComponentHandlers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// checkInteractionMessage returns an error when handlers can not be added to an InteractionMessage, as there is no message,
+// such as for the deferred response to a TextCommand.
+func checkInteractionMessage(f *InteractionMessage) error {
+	if f == nil || f.Message == nil {
+		return errors.New("the InteractionMessage has no message")
+	}
+	return nil
+}
+
+// forgetMessageHandler stops counting a component handler that was added to an InteractionMessage, if it was. The lock of the handlers has to be held.
+func (h *Harmonia) forgetMessageHandler(key string) {
+	if h.messageHandlers[key] {
+		delete(h.messageHandlers, key)
+		h.metrics().MessageHandlers(len(h.messageHandlers))
+	}
+}
+
 // Run starts the Harmonia bot up and does the handling for slash commands and components for you.
+// It is composed of Dispatch, which handles every incoming Interaction, and RegisterCommands, which is called once the gateway is open.
+// DispatchMessage handles every incoming message, so TextCommands that are added after Run are handled as well. Other events are handled by DispatchEvent.
 func (h *Harmonia) Run() error {
 	h.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		switch i.Type {
-		case discordgo.InteractionApplicationCommand:
-			if command, ok := h.Commands[i.ApplicationCommandData().Name]; ok {
-				guild, _ := h.Guild(i.GuildID)
-				channel, _ := h.Channel(i.ChannelID)
-				author, _ := AuthorFromInteraction(h, i.Interaction)
-				options := i.ApplicationCommandData().Options
-
-				command.Do(h, &Invocation{
-					Interaction: i.Interaction,
-					Guild:       guild,
-					Channel:     channel,
-					Author:      author,
-					options:     options,
-					targetID:    i.ApplicationCommandData().TargetID,
-				})
-			}
-			return
-		case discordgo.InteractionMessageComponent:
-			if componentHandler, ok := h.ComponentHandlers[i.MessageComponentData().CustomID]; ok {
-				guild, _ := h.Guild(i.GuildID)
-				channel, _ := h.Channel(i.ChannelID)
-				author, _ := AuthorFromInteraction(h, i.Interaction)
-				values := i.MessageComponentData().Values
-
-				componentHandler(h, &Invocation{
-					Interaction: i.Interaction,
-					Guild:       guild,
-					Channel:     channel,
-					Author:      author,
-					Values:      values,
-				})
-				return
-			}
-
-			followupcustomID := fmt.Sprintf("%v-%v", i.Message.ID, i.MessageComponentData().CustomID)
+		h.Dispatch(i)
+	})
+	h.AddHandler(func(s *discordgo.Session, event interface{}) {
+		h.DispatchEvent(event)
+	})
 
-			if componentHandler, ok := h.ComponentHandlers[followupcustomID]; ok {
-				guild, _ := h.Guild(i.GuildID)
-				channel, _ := h.Channel(i.ChannelID)
-				author, _ := AuthorFromInteraction(h, i.Interaction)
-				values := i.MessageComponentData().Values
+	if h.hasListeners(eventMemberJoin) || h.hasListeners(eventMemberLeave) {
+		h.Identify.Intents |= discordgo.IntentGuildMembers
+	}
 
-				componentHandler(h, &Invocation{
-					Interaction: i.Interaction,
-					Guild:       guild,
-					Channel:     channel,
-					Author:      author,
-					Values:      values,
-				})
-				return
-			}
-		}
+	// DispatchMessage ignores messages while there is no Prefix or no TextCommands, so it is added even when none are added yet.
+	h.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
+		h.DispatchMessage(m)
 	})
+	if h.Prefix != nil {
+		// Reading the content of messages requires the privileged Message Content intent, which has to be enabled for the bot.
+		// It is requested when a Prefix is set, rather than when TextCommands are added, as they can still be added after Run.
+		h.Identify.Intents |= discordgo.IntentMessageContent
+	}
 
 	err := h.Open()
 	if err != nil {
 		return err
 	}
 
-	for _, command := range h.Commands {
-		data := command.getRegistration()
-		registration, err := h.ApplicationCommandCreate(h.State.User.ID, data.GuildID, data)
+	return h.RegisterCommands(context.Background())
+}
+
+// RegisterCommands registers all commands added to Harmonia with the Discord API, without having to open a gateway connection.
+// This allows commands to be registered separately from handling them, such as from a CI job.
+func (h *Harmonia) RegisterCommands(ctx context.Context) error {
+	appID := h.appID()
+	if appID == "" {
+		user, err := h.RESTClient().User("@me", discordgo.WithContext(ctx))
 		if err != nil {
 			return err
 		}
-		command.setRegistration(registration)
+		appID = user.ID
+		h.ApplicationID = appID
+	}
+
+	// Modules loaded from here on register their own commands, so none are missed while the others are registered.
+	// The commands are taken while modules can not be loaded, so that those of a module are not registered twice.
+	h.modulesMu.Lock()
+	h.registered = true
+	h.handlersMu.RLock()
+	commands := make([]CommandHandler, 0, len(h.Commands))
+	for _, command := range h.Commands {
+		commands = append(commands, command)
+	}
+	h.handlersMu.RUnlock()
+	h.modulesMu.Unlock()
+
+	for _, command := range commands {
+		if err := h.registerCommand(ctx, command); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// registerCommand registers a single command with the Discord API.
+func (h *Harmonia) registerCommand(ctx context.Context, command CommandHandler) error {
+	data := h.registration(command)
+	registration, err := h.RESTClient().ApplicationCommandCreate(h.appID(), data.GuildID, data, discordgo.WithContext(ctx))
+	if err != nil {
+		h.logger().Error("could not register command", "command", data.Name, "guild_id", data.GuildID, "error", err)
+		return err
+	}
+	h.setRegistration(command, registration)
+	h.logger().Info("registered command", "command", registration.Name, "command_id", registration.ID, "guild_id", registration.GuildID)
+	return nil
+}
+
+// Dispatch handles an incoming Interaction, calling the command or component handler it was meant for.
+// Run calls Dispatch for every Interaction received from the gateway. Command handlers run in their own goroutine, see Wait.
+func (h *Harmonia) Dispatch(i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		if command, ok := h.command(i.ApplicationCommandData().Name); ok {
+			invocation := h.newInvocation(i.Interaction)
+			invocation.options = i.ApplicationCommandData().Options
+			invocation.targetID = i.ApplicationCommandData().TargetID
+			invocation.resolved = i.ApplicationCommandData().Resolved
+			invocation.setCommand(command, i.ApplicationCommandData().ID, invocation.options)
+
+			h.logger().Debug("dispatching command", invocation.logFields()...)
+			h.metrics().CommandInvoked(invocation.handlerName())
+			h.startInvocationSpan(invocation, "harmonia.command")
+			if h.EagerResolve {
+				invocation.resolve()
+			}
+			command.Do(h, invocation)
+			if !invocation.invoked {
+				invocation.endInvocationSpan()
+			}
+			return
+		}
+
+		invocation := h.newInvocation(i.Interaction)
+		invocation.options = i.ApplicationCommandData().Options
+		invocation.CommandPath = []string{i.ApplicationCommandData().Name}
+		invocation.CommandID = i.ApplicationCommandData().ID
+
+		h.logger().Warn("unknown command", invocation.logFields()...)
+		h.startInvocationSpan(invocation, "harmonia.command")
+		if h.EagerResolve {
+			invocation.resolve()
+		}
+		h.invoke(invocation, nil, h.unknownCommandHandler())
+		return
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		if command, ok := h.command(i.ApplicationCommandData().Name); ok {
+			h.autocomplete(i.Interaction, command)
+			return
+		}
+		h.logger().Warn("unknown command", "interaction_id", i.ID, "command", i.ApplicationCommandData().Name)
+		return
+	case discordgo.InteractionMessageComponent:
+		componentHandler, ok := h.componentHandler(i.MessageComponentData().CustomID, i.Message.ID)
+
+		invocation := h.newInvocation(i.Interaction)
+		invocation.Values = i.MessageComponentData().Values
+
+		if !ok {
+			invocation.unknownComponent = true
+			h.logger().Warn("unknown component", invocation.logFields("custom_id", i.MessageComponentData().CustomID, "message_id", i.Message.ID)...)
+			h.startInvocationSpan(invocation, "harmonia.component", "custom_id", i.MessageComponentData().CustomID)
+			if h.EagerResolve {
+				invocation.resolve()
+			}
+			h.invoke(invocation, nil, h.unknownComponentHandler())
+			return
+		}
+
+		h.logger().Debug("dispatching component", invocation.logFields("custom_id", i.MessageComponentData().CustomID)...)
+		h.metrics().ComponentClicked(i.MessageComponentData().CustomID)
+		h.startInvocationSpan(invocation, "harmonia.component", "custom_id", i.MessageComponentData().CustomID)
+		if h.EagerResolve {
+			invocation.resolve()
+		}
+		h.invoke(invocation, nil, componentHandler)
+	}
+}
+
+// DefaultUnknownCommand tells the invoker that the command is no longer available, only they can see the message.
+func DefaultUnknownCommand(h *Harmonia, i *Invocation) {
+	if _, err := h.EphemeralRespond(i, "This command is no longer available."); err != nil {
+		h.logger().Error("could not respond to unknown command", i.logFields("error", err)...)
+	}
+}
+
+// DefaultUnknownComponent tells the invoker that the component has expired, only they can see the message.
+func DefaultUnknownComponent(h *Harmonia, i *Invocation) {
+	if _, err := h.EphemeralRespond(i, "This button has expired."); err != nil {
+		h.logger().Error("could not respond to unknown component", i.logFields("error", err)...)
+	}
+}
+
+func (h *Harmonia) unknownCommandHandler() CommandFunc {
+	if h.OnUnknownCommand == nil {
+		return DefaultUnknownCommand
+	}
+	return h.OnUnknownCommand
+}
+
+func (h *Harmonia) unknownComponentHandler() CommandFunc {
+	if h.OnUnknownComponent == nil {
+		return DefaultUnknownComponent
+	}
+	return h.OnUnknownComponent
+}
+
+// autocomplete responds to an autocomplete Interaction with the choices of the AutocompleteFunc of the focused option.
+func (h *Harmonia) autocomplete(interaction *discordgo.Interaction, command CommandHandler) {
+	subcommand, _, options := resolveSubcommand(command, interaction.ApplicationCommandData().Options)
+	s, ok := subcommand.(*SlashCommand)
+	if !ok {
+		return
+	}
+
+	for _, focused := range options {
+		if !focused.Focused {
+			continue
+		}
+
+		for _, option := range s.options {
+			if option.Name != focused.Name || option.autocomplete == nil {
+				continue
+			}
+
+			option, focused := option, focused
+			i := h.newInvocation(interaction)
+			i.options = options
+			i.setCommand(command, interaction.ApplicationCommandData().ID, interaction.ApplicationCommandData().Options)
+			h.startInvocationSpan(i, "harmonia.autocomplete", "option", option.Name)
+			if h.EagerResolve {
+				i.resolve()
+			}
+			h.invoke(i, nil, func(h *Harmonia, i *Invocation) {
+				choices := option.autocomplete(h, i, fmt.Sprint(focused.Value))
+				if len(choices) > 25 {
+					choices = choices[:25]
+				}
+				err := h.RESTClient().InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+					Data: &discordgo.InteractionResponseData{Choices: choices},
+				})
+				h.observeError(i, err)
+				if err != nil {
+					h.logger().Error("could not respond with autocomplete choices", i.logFields("error", err)...)
+				}
+			})
+		}
+		return
+	}
+}
+
+// Wait blocks until all handlers started by Dispatch have returned.
+func (h *Harmonia) Wait() {
+	h.running.wait()
+}
+
+// handlerTracker keeps count of the handlers that are running, its zero value is ready to use.
+type handlerTracker struct {
+	mu      sync.Mutex
+	count   int
+	waiters []chan struct{}
+}
+
+func (t *handlerTracker) add() {
+	t.mu.Lock()
+	t.count++
+	t.mu.Unlock()
+}
+
+func (t *handlerTracker) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.count--
+	if t.count == 0 {
+		for _, waiter := range t.waiters {
+			close(waiter)
+		}
+		t.waiters = nil
+	}
+}
+
+func (t *handlerTracker) wait() {
+	t.mu.Lock()
+	if t.count == 0 {
+		t.mu.Unlock()
+		return
+	}
+
+	waiter := make(chan struct{})
+	t.waiters = append(t.waiters, waiter)
+	t.mu.Unlock()
+	<-waiter
+}
+
 // RemoveCommand removes a slash command from Harmonia and from the Discord API.
 func (h *Harmonia) RemoveCommand(name string) error {
-	command, ok := h.Commands[name]
+	command, ok := h.command(name)
 	if !ok {
 		return fmt.Errorf("command '%v' was not found", name)
 	}
 
-	registration := command.getRegistration()
+	registration := h.registration(command)
 
 	if registration.ID == "" {
 		return fmt.Errorf("command '%v' was not registered", name)
 	}
 
-	err := h.ApplicationCommandDelete(h.State.User.ID, registration.GuildID, registration.ID)
+	err := h.RESTClient().ApplicationCommandDelete(h.appID(), registration.GuildID, registration.ID)
 	if err != nil {
 		return err
 	}
 
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
 	delete(h.Commands, name)
 	return nil
 }
 
-// RemoveAllCommands does removes all registered commands from the Discord API.
-func (h *Harmonia) RemoveAllCommands() error {
-	globals, err := h.ApplicationCommands(h.State.User.ID, "")
-	if err != nil {
+// UpdateCommand replaces the command with the same name as the given one while the bot is running, such as to change its description or options.
+// When the command was registered, its registration is edited with the Discord API first, keeping its ID. A command can also be updated by changing it and passing it again.
+//
+// The command is swapped once the registration was edited: Invocations that are already running finish with the old command, while new ones use the updated command.
+// A hybrid command is updated as a TextCommand as well. The type of a command can not be changed, remove it and add it again instead.
+func (h *Harmonia) UpdateCommand(command CommandHandler) error {
+	if err := newValidationError(validateCommand(command)); err != nil {
 		return err
 	}
 
-	for _, global := range globals {
-		err := h.ApplicationCommandDelete(h.State.User.ID, global.GuildID, global.ID)
+	// Modules are kept from loading and unloading, so the command is also swapped in the Module it belongs to.
+	h.modulesMu.Lock()
+	defer h.modulesMu.Unlock()
+
+	name := command.GetName()
+	old, ok := h.command(name)
+	if !ok {
+		return fmt.Errorf("command '%v' was not found", name)
+	}
+
+	// The registration of the command is only replaced once it was edited, as the command may be the one that is being dispatched.
+	registered := h.registration(old)
+	data := command.buildRegistration()
+	if data.Type != registered.Type {
+		return fmt.Errorf("command '%v' can not change its type", name)
+	}
+
+	var registration *discordgo.ApplicationCommand
+	if registered.ID != "" {
+		var err error
+		registration, err = h.editRegistration(registered, data)
 		if err != nil {
+			h.logger().Error("could not update command", "command", name, "command_id", registered.ID, "error", err)
 			return err
 		}
+		h.logger().Info("updated command", "command", name, "command_id", registration.ID, "guild_id", registration.GuildID)
 	}
 
-	for _, guild := range h.State.Guilds {
-		locals, err := h.ApplicationCommands(h.State.User.ID, guild.ID)
+	h.handlersMu.Lock()
+	command.setRegistration(registration)
+	h.Commands[name] = command
+	updated := make(map[*TextCommand]*TextCommand)
+	for key, text := range h.TextCommands {
+		if text.handler != old {
+			continue
+		}
+		// The TextCommand is copied, as DispatchMessage may be using it without holding the lock.
+		if _, ok := updated[text]; !ok {
+			copied := *text
+			copied.handler = command
+			updated[text] = &copied
+		}
+		h.TextCommands[key] = updated[text]
+	}
+	h.handlersMu.Unlock()
+
+	for _, loaded := range h.modules {
+		for n, other := range loaded.commands {
+			if other == old {
+				loaded.commands[n] = command
+			}
+		}
+	}
+	return nil
+}
+
+// registration returns the registration of a command, which UpdateCommand can replace while it is dispatched.
+func (h *Harmonia) registration(command CommandHandler) *discordgo.ApplicationCommand {
+	h.handlersMu.RLock()
+	defer h.handlersMu.RUnlock()
+	return command.getRegistration()
+}
+
+// setRegistration sets the registration of a command while holding the lock of the handlers.
+func (h *Harmonia) setRegistration(command CommandHandler, registration *discordgo.ApplicationCommand) {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+	command.setRegistration(registration)
+}
+
+// editRegistration edits a registered command. A command can not be moved to another guild, so it is registered there and removed from where it was instead.
+func (h *Harmonia) editRegistration(registered, data *discordgo.ApplicationCommand) (*discordgo.ApplicationCommand, error) {
+	if data.GuildID == registered.GuildID {
+		return h.RESTClient().ApplicationCommandEdit(h.appID(), registered.GuildID, registered.ID, data)
+	}
+
+	registration, err := h.RESTClient().ApplicationCommandCreate(h.appID(), data.GuildID, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.RESTClient().ApplicationCommandDelete(h.appID(), registered.GuildID, registered.ID); err != nil {
+		h.logger().Warn("could not remove command from its old guild", "command", registered.Name, "command_id", registered.ID, "guild_id", registered.GuildID, "error", err)
+	}
+	return registration, nil
+}
+
+// RemoveAllCommands does removes all registered commands from the Discord API.
+func (h *Harmonia) RemoveAllCommands() error {
+	globals, err := h.RESTClient().ApplicationCommands(h.appID(), "")
+	if err != nil {
+		return err
+	}
+
+	for _, global := range globals {
+		err := h.RESTClient().ApplicationCommandDelete(h.appID(), global.GuildID, global.ID)
 		if err != nil {
 			return err
 		}
+	}
 
-		for _, local := range locals {
-			err := h.ApplicationCommandDelete(h.State.User.ID, local.GuildID, local.ID)
+	if state := h.state(); state != nil {
+		for _, guild := range state.Guilds {
+			locals, err := h.RESTClient().ApplicationCommands(h.appID(), guild.ID)
 			if err != nil {
 				return err
 			}
+
+			for _, local := range locals {
+				err := h.RESTClient().ApplicationCommandDelete(h.appID(), local.GuildID, local.ID)
+				if err != nil {
+					return err
+				}
+			}
 		}
 	}
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
 	h.Commands = make(map[string]CommandHandler)
 	return nil
 }
@@ -377,4 +919,7 @@ type InteractionMessage struct {
 	Interaction *discordgo.Interaction
 	Channel     *discordgo.Channel
 	Guild       *discordgo.Guild
+
+	// text is set when the message was sent as a reply to a TextCommand, instead of through the Interaction.
+	text bool
 }
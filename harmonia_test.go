@@ -1,8 +1,12 @@
 package harmonia
 
 import (
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/bwmarrin/discordgo"
@@ -21,7 +25,7 @@ var (
 func TestMain(m *testing.M) {
 	err := godotenv.Load()
 	if err != nil {
-		log.Panic("Error loading .env file")
+		log.Println("No .env file loaded, tests that need H_TOKEN will be skipped")
 	}
 
 	envBotToken = os.Getenv("H_TOKEN")
@@ -51,7 +55,7 @@ func TestNewToken(t *testing.T) {
 func TestAddCommand(t *testing.T) {
 	harm := &Harmonia{Commands: make(map[string]CommandHandler)}
 
-	command := NewSlashCommand("test")
+	command := NewSlashCommand("test").WithDescription("Tests")
 	t.Run("Correct Slash Command", func(t *testing.T) {
 		err := harm.AddCommand(command)
 		assert.Nil(t, err)
@@ -63,6 +67,28 @@ func TestAddCommand(t *testing.T) {
 	})
 }
 
+func TestComponentHandlerIDs(t *testing.T) {
+	harm := &Harmonia{ComponentHandlers: make(map[string]CommandFunc)}
+	harm.AddComponentHandler("global", func(h *Harmonia, i *Invocation) {})
+	harm.AddComponentHandlerToInteractionMessage(&InteractionMessage{Message: &discordgo.Message{ID: "123"}}, "confirm", func(h *Harmonia, i *Invocation) {})
+	assert.Equal(t, []string{"123-confirm", "global"}, harm.ComponentHandlerIDs())
+
+	// Handlers can be added and removed while the IDs are read.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for n := 0; n < 100; n++ {
+			harm.AddComponentHandler("temporary", func(h *Harmonia, i *Invocation) {})
+			harm.RemoveComponentHandler("temporary")
+		}
+	}()
+	for n := 0; n < 100; n++ {
+		harm.ComponentHandlerIDs()
+	}
+	<-done
+	assert.Len(t, harm.ComponentHandlerIDs(), 2)
+}
+
 func TestParseComponentMatrix(t *testing.T) {
 	components := [][]discordgo.MessageComponent{
 		{
@@ -82,3 +108,75 @@ func TestParseComponentMatrix(t *testing.T) {
 
 	assert.Equal(t, correctMatrix, parsedMatrix)
 }
+
+// countingTransport answers every request to the Discord API with an empty object, counting the requests made.
+type countingTransport struct {
+	requests int64
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&t.requests, 1)
+
+	body := `{"id":"guild","roles":[{"id":"guild"}]}`
+	if strings.HasPrefix(r.URL.Path, "/api/v9/channels/") {
+		body = `{"id":"channel","guild_id":"guild"}`
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    r,
+	}, nil
+}
+
+func BenchmarkDispatch(b *testing.B) {
+	interaction := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		Data:      discordgo.ApplicationCommandInteractionData{Name: "bench"},
+		GuildID:   "guild",
+		ChannelID: "channel",
+		Member:    &discordgo.Member{User: &discordgo.User{ID: "user"}, Roles: []string{"role"}},
+	}}
+
+	newHarmonia := func(b *testing.B) (*Harmonia, *countingTransport) {
+		harm, err := New("token")
+		if err != nil {
+			b.Fatal(err)
+		}
+		transport := &countingTransport{}
+		harm.Client = &http.Client{Transport: transport}
+		harm.AddCommand(NewSlashCommand("bench").WithDescription("Benchmarks").WithCommand(func(h *Harmonia, i *Invocation) {}))
+		return harm, transport
+	}
+
+	b.Run("Lazy", func(b *testing.B) {
+		harm, transport := newHarmonia(b)
+		for n := 0; n < b.N; n++ {
+			harm.Dispatch(interaction)
+		}
+		b.ReportMetric(float64(atomic.LoadInt64(&transport.requests))/float64(b.N), "requests/op")
+	})
+
+	b.Run("EagerColdCache", func(b *testing.B) {
+		harm, transport := newHarmonia(b)
+		harm.EagerResolve = true
+		for n := 0; n < b.N; n++ {
+			harm.Dispatch(interaction)
+		}
+		b.ReportMetric(float64(atomic.LoadInt64(&transport.requests))/float64(b.N), "requests/op")
+	})
+
+	b.Run("EagerWarmCache", func(b *testing.B) {
+		harm, transport := newHarmonia(b)
+		harm.EagerResolve = true
+		guild := &discordgo.Guild{ID: "guild", Roles: []*discordgo.Role{{ID: "guild"}, {ID: "role"}}}
+		harm.State.GuildAdd(guild)
+		harm.State.ChannelAdd(&discordgo.Channel{ID: "channel", GuildID: "guild"})
+
+		for n := 0; n < b.N; n++ {
+			harm.Dispatch(interaction)
+		}
+		b.ReportMetric(float64(atomic.LoadInt64(&transport.requests))/float64(b.N), "requests/op")
+	})
+}
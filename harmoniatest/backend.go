@@ -0,0 +1,560 @@
+package harmoniatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A CallKind describes what kind of request was made to the Backend.
+type CallKind int
+
+// The kinds of calls recorded by the Backend.
+const (
+	// CallResponse is an initial response to an Interaction, see Harmonia.RespondComplex and Harmonia.DeferResponse.
+	CallResponse CallKind = iota
+	// CallFollowup is a follow-up message to an Interaction, see Harmonia.FollowupComplex.
+	CallFollowup
+	// CallEdit is an edit of a response or follow-up message, see Harmonia.EditResponse and Harmonia.EditFollowup.
+	CallEdit
+	// CallDelete is a deletion of a response or follow-up message, see Harmonia.DeleteResponse and Harmonia.DeleteFollowup.
+	CallDelete
+	// CallMessage is a message sent to a channel, such as a reply to a TextCommand.
+	CallMessage
+	// CallTyping is a typing indicator in a channel, which is how a TextCommand defers its response.
+	CallTyping
+)
+
+func (k CallKind) String() string {
+	switch k {
+	case CallResponse:
+		return "response"
+	case CallFollowup:
+		return "followup"
+	case CallEdit:
+		return "edit"
+	case CallDelete:
+		return "delete"
+	case CallMessage:
+		return "message"
+	case CallTyping:
+		return "typing"
+	}
+	return fmt.Sprintf("CallKind(%d)", int(k))
+}
+
+// A Call is a single request Harmonia made to the Backend on behalf of an Interaction.
+type Call struct {
+	Kind CallKind
+
+	// Token is the token of the Interaction the call was made for, calls made for a TextCommand have no token.
+	Token string
+
+	// ResponseType is the type of the response, only set for calls of kind CallResponse.
+	ResponseType discordgo.InteractionResponseType
+
+	// Message contains the content, embeds, components and flags that were sent, with the ID of the message that was affected.
+	Message *discordgo.Message
+
+	// Choices are the suggested choices, only set for responses of type InteractionApplicationCommandAutocompleteResult.
+	Choices []*discordgo.ApplicationCommandOptionChoice
+}
+
+// A Backend is a fake Discord REST API, serving the endpoints Harmonia uses and recording the calls made to it.
+type Backend struct {
+	Server *httptest.Server
+
+	mu        sync.Mutex
+	nextID    int
+	calls     []*Call
+	originals map[string]string
+	sources   map[string]string
+	messages  map[string]*discordgo.Message
+	guilds    map[string]*discordgo.Guild
+	channels  map[string]*discordgo.Channel
+	members   map[string]*discordgo.Member
+	users     map[string]*discordgo.User
+	commands  map[string]*discordgo.ApplicationCommand
+}
+
+// NewBackend starts a new Backend, it should be closed with Close when it is no longer needed.
+func NewBackend() *Backend {
+	b := &Backend{
+		nextID:    1000,
+		originals: make(map[string]string),
+		sources:   make(map[string]string),
+		messages:  make(map[string]*discordgo.Message),
+		guilds:    make(map[string]*discordgo.Guild),
+		channels:  make(map[string]*discordgo.Channel),
+		members:   make(map[string]*discordgo.Member),
+		users:     make(map[string]*discordgo.User),
+		commands:  make(map[string]*discordgo.ApplicationCommand),
+	}
+	b.Server = httptest.NewServer(http.HandlerFunc(b.serveHTTP))
+	return b
+}
+
+// Close shuts down the Backend.
+func (b *Backend) Close() {
+	b.Server.Close()
+}
+
+// Client returns an http.Client that sends every request meant for the Discord API to the Backend instead.
+func (b *Backend) Client() *http.Client {
+	target, _ := url.Parse(b.Server.URL)
+	return &http.Client{Transport: rewriteTransport{target: target}}
+}
+
+// AddGuild makes the Backend serve the guild and its roles.
+func (b *Backend) AddGuild(guild *discordgo.Guild) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.guilds[guild.ID] = guild
+}
+
+// AddChannel makes the Backend serve the channel.
+func (b *Backend) AddChannel(channel *discordgo.Channel) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.channels[channel.ID] = channel
+}
+
+func (b *Backend) addMissingGuild(guild *discordgo.Guild) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.guilds[guild.ID]; !ok {
+		b.guilds[guild.ID] = guild
+	}
+}
+
+func (b *Backend) addMissingChannel(channel *discordgo.Channel) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.channels[channel.ID]; !ok {
+		b.channels[channel.ID] = channel
+	}
+}
+
+func (b *Backend) addMissingMember(guildID string, member *discordgo.Member) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.members[guildID+"/"+member.User.ID]; !ok {
+		b.members[guildID+"/"+member.User.ID] = member
+	}
+}
+
+// AddMember makes the Backend serve the member of the guild and its user.
+func (b *Backend) AddMember(guildID string, member *discordgo.Member) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.members[guildID+"/"+member.User.ID] = member
+	b.users[member.User.ID] = member.User
+}
+
+// SetCurrentUser makes the Backend serve the user as the user the bot is logged in as.
+func (b *Backend) SetCurrentUser(user *discordgo.User) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.users["@me"] = user
+}
+
+// AddUser makes the Backend serve the user.
+func (b *Backend) AddUser(user *discordgo.User) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.users[user.ID] = user
+}
+
+// Calls returns all calls made to the Backend so far, in the order they were made.
+func (b *Backend) Calls() []*Call {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*Call(nil), b.calls...)
+}
+
+// Commands returns the application commands that are currently registered with the Backend.
+func (b *Backend) Commands() []*discordgo.ApplicationCommand {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	commands := make([]*discordgo.ApplicationCommand, 0, len(b.commands))
+	for _, command := range b.commands {
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+// Member returns the member of the guild with the given user ID as it currently is, or nil if it is not served.
+func (b *Backend) Member(guildID, userID string) *discordgo.Member {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.members[guildID+"/"+userID]
+}
+
+// Message returns the message with the given ID as it currently looks, or nil if it does not exist.
+func (b *Backend) Message(id string) *discordgo.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.messages[id]
+}
+
+func (b *Backend) newID() string {
+	b.nextID++
+	return fmt.Sprint(b.nextID)
+}
+
+func (b *Backend) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	path := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v"+discordgo.APIVersion), "/"), "/")
+	route := r.Method + " " + pattern(path)
+
+	switch route {
+	case "POST interactions/*/*/callback":
+		b.respond(w, r, path[2])
+	case "GET webhooks/*/*/messages/*":
+		message, ok := b.messages[b.messageID(path[2], path[4])]
+		writeFound(w, message, ok)
+	case "PATCH webhooks/*/*/messages/*":
+		b.editMessage(w, r, path[2], b.messageID(path[2], path[4]))
+	case "DELETE webhooks/*/*/messages/*":
+		b.deleteMessage(w, path[2], b.messageID(path[2], path[4]))
+	case "POST webhooks/*/*":
+		b.followup(w, r, path[2])
+	case "GET guilds/*":
+		guild, ok := b.guilds[path[1]]
+		writeFound(w, guild, ok)
+	case "GET guilds/*/roles":
+		guild, ok := b.guilds[path[1]]
+		if ok {
+			writeJSON(w, http.StatusOK, guild.Roles)
+			return
+		}
+		writeFound(w, nil, false)
+	case "GET guilds/*/members/*":
+		member, ok := b.members[path[1]+"/"+path[3]]
+		writeFound(w, member, ok)
+	case "PUT guilds/*/members/*/roles/*", "DELETE guilds/*/members/*/roles/*":
+		b.changeRole(w, r.Method == http.MethodPut, path[1], path[3], path[5])
+	case "GET channels/*":
+		channel, ok := b.channels[path[1]]
+		writeFound(w, channel, ok)
+	case "POST channels/*/messages":
+		b.createMessage(w, r, path[1])
+	case "GET channels/*/messages/*":
+		message, ok := b.messages[path[3]]
+		writeFound(w, message, ok)
+	case "PATCH channels/*/messages/*":
+		b.editMessage(w, r, "", path[3])
+	case "DELETE channels/*/messages/*":
+		b.deleteMessage(w, "", path[3])
+	case "PUT channels/*/messages/*/reactions/*/*":
+		b.addReaction(w, path[3], path[5])
+	case "POST channels/*/typing":
+		b.calls = append(b.calls, &Call{Kind: CallTyping, Message: &discordgo.Message{ChannelID: path[1]}})
+		w.WriteHeader(http.StatusNoContent)
+	case "GET users/*":
+		user, ok := b.users[path[1]]
+		writeFound(w, user, ok)
+	case "POST applications/*/commands", "POST applications/*/guilds/*/commands":
+		b.createCommand(w, r)
+	case "GET applications/*/commands":
+		b.listCommands(w, "")
+	case "GET applications/*/guilds/*/commands":
+		b.listCommands(w, path[3])
+	case "PATCH applications/*/commands/*":
+		b.editCommand(w, r, path[3])
+	case "PATCH applications/*/guilds/*/commands/*":
+		b.editCommand(w, r, path[5])
+	case "DELETE applications/*/commands/*":
+		b.deleteCommand(w, path[3])
+	case "DELETE applications/*/guilds/*/commands/*":
+		b.deleteCommand(w, path[5])
+	default:
+		writeJSON(w, http.StatusNotFound, &discordgo.APIErrorMessage{Message: "harmoniatest: unknown route " + route})
+	}
+}
+
+// changeRole adds a role to or removes it from a member. Members that are not served by the Backend yet are added.
+func (b *Backend) changeRole(w http.ResponseWriter, add bool, guildID, userID, roleID string) {
+	member, ok := b.members[guildID+"/"+userID]
+	if !ok {
+		member = &discordgo.Member{GuildID: guildID, User: &discordgo.User{ID: userID}}
+		b.members[guildID+"/"+userID] = member
+	}
+
+	roles := make([]string, 0, len(member.Roles)+1)
+	for _, id := range member.Roles {
+		if id != roleID {
+			roles = append(roles, id)
+		}
+	}
+	if add {
+		roles = append(roles, roleID)
+	}
+	member.Roles = roles
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// addReaction adds a reaction of the bot to a message, the emoji is the unicode emoji, or "name:id" for a custom emoji.
+func (b *Backend) addReaction(w http.ResponseWriter, messageID, emoji string) {
+	message, ok := b.messages[messageID]
+	if !ok {
+		writeFound(w, nil, false)
+		return
+	}
+
+	for _, reaction := range message.Reactions {
+		if reaction.Emoji.APIName() == emoji {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	name, id, _ := strings.Cut(emoji, ":")
+	message.Reactions = append(message.Reactions, &discordgo.MessageReactions{Count: 1, Me: true, Emoji: &discordgo.Emoji{Name: name, ID: id}})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setSource sets the message the component of an Interaction was clicked on.
+func (b *Backend) setSource(token, messageID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sources[token] = messageID
+}
+
+// messageID resolves the "@original" message ID of an Interaction token.
+func (b *Backend) messageID(token, id string) string {
+	if id == "@original" {
+		return b.originals[token]
+	}
+	return id
+}
+
+func (b *Backend) respond(w http.ResponseWriter, r *http.Request, token string) {
+	var resp struct {
+		Type discordgo.InteractionResponseType `json:"type"`
+		Data json.RawMessage                   `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+		writeJSON(w, http.StatusBadRequest, &discordgo.APIErrorMessage{Message: err.Error()})
+		return
+	}
+
+	message := &discordgo.Message{}
+	data := &discordgo.InteractionResponseData{}
+	if len(resp.Data) > 0 {
+		json.Unmarshal(resp.Data, message)
+		json.Unmarshal(resp.Data, data)
+	}
+
+	switch resp.Type {
+	case discordgo.InteractionResponseUpdateMessage, discordgo.InteractionResponseDeferredMessageUpdate:
+		// Updating a message edits the message the component was clicked on.
+		message.ID = b.sources[token]
+		b.originals[token] = message.ID
+		if source, ok := b.messages[message.ID]; ok && resp.Type == discordgo.InteractionResponseDeferredMessageUpdate {
+			message = source
+		}
+		b.messages[message.ID] = message
+	case discordgo.InteractionApplicationCommandAutocompleteResult:
+	default:
+		message.ID = b.newID()
+		b.originals[token] = message.ID
+		b.messages[message.ID] = message
+	}
+	b.calls = append(b.calls, &Call{Kind: CallResponse, Token: token, ResponseType: resp.Type, Message: message, Choices: data.Choices})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (b *Backend) followup(w http.ResponseWriter, r *http.Request, token string) {
+	message := &discordgo.Message{}
+	if err := json.NewDecoder(r.Body).Decode(message); err != nil {
+		writeJSON(w, http.StatusBadRequest, &discordgo.APIErrorMessage{Message: err.Error()})
+		return
+	}
+	message.ID = b.newID()
+
+	b.messages[message.ID] = message
+	b.calls = append(b.calls, &Call{Kind: CallFollowup, Token: token, Message: message})
+	writeJSON(w, http.StatusOK, message)
+}
+
+func (b *Backend) createMessage(w http.ResponseWriter, r *http.Request, channelID string) {
+	var send struct {
+		Reference *discordgo.MessageReference `json:"message_reference"`
+	}
+	body, _ := io.ReadAll(r.Body)
+	if err := json.Unmarshal(body, &send); err != nil {
+		writeJSON(w, http.StatusBadRequest, &discordgo.APIErrorMessage{Message: err.Error()})
+		return
+	}
+
+	message := &discordgo.Message{}
+	json.Unmarshal(body, message)
+	message.ID = b.newID()
+	message.ChannelID = channelID
+	message.MessageReference = send.Reference
+	if channel, ok := b.channels[channelID]; ok {
+		message.GuildID = channel.GuildID
+	}
+	if bot, ok := b.users["@me"]; ok {
+		message.Author = bot
+	}
+
+	b.messages[message.ID] = message
+	b.calls = append(b.calls, &Call{Kind: CallMessage, Message: message})
+	writeJSON(w, http.StatusOK, message)
+}
+
+func (b *Backend) editMessage(w http.ResponseWriter, r *http.Request, token, id string) {
+	message, ok := b.messages[id]
+	if !ok {
+		writeFound(w, nil, false)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		writeJSON(w, http.StatusBadRequest, &discordgo.APIErrorMessage{Message: err.Error()})
+		return
+	}
+	edit := &discordgo.Message{}
+	json.Unmarshal(body, edit)
+	edit.ID = id
+
+	updated := *message
+	if _, ok := raw["content"]; ok {
+		updated.Content = edit.Content
+	}
+	if _, ok := raw["components"]; ok {
+		updated.Components = edit.Components
+	}
+	if _, ok := raw["embeds"]; ok {
+		updated.Embeds = edit.Embeds
+	}
+	b.messages[id] = &updated
+
+	b.calls = append(b.calls, &Call{Kind: CallEdit, Token: token, Message: edit})
+	writeJSON(w, http.StatusOK, &updated)
+}
+
+func (b *Backend) deleteMessage(w http.ResponseWriter, token, id string) {
+	if _, ok := b.messages[id]; !ok {
+		writeFound(w, nil, false)
+		return
+	}
+	delete(b.messages, id)
+
+	b.calls = append(b.calls, &Call{Kind: CallDelete, Token: token, Message: &discordgo.Message{ID: id}})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (b *Backend) createCommand(w http.ResponseWriter, r *http.Request) {
+	command := &discordgo.ApplicationCommand{}
+	if err := json.NewDecoder(r.Body).Decode(command); err != nil {
+		writeJSON(w, http.StatusBadRequest, &discordgo.APIErrorMessage{Message: err.Error()})
+		return
+	}
+
+	for _, existing := range b.commands {
+		if existing.Name == command.Name && existing.GuildID == command.GuildID && existing.Type == command.Type {
+			command.ID = existing.ID
+		}
+	}
+	if command.ID == "" {
+		command.ID = b.newID()
+	}
+
+	b.commands[command.ID] = command
+	writeJSON(w, http.StatusCreated, command)
+}
+
+func (b *Backend) listCommands(w http.ResponseWriter, guildID string) {
+	commands := make([]*discordgo.ApplicationCommand, 0)
+	for _, command := range b.commands {
+		if command.GuildID == guildID {
+			commands = append(commands, command)
+		}
+	}
+	writeJSON(w, http.StatusOK, commands)
+}
+
+func (b *Backend) editCommand(w http.ResponseWriter, r *http.Request, id string) {
+	existing, ok := b.commands[id]
+	if !ok {
+		writeFound(w, nil, false)
+		return
+	}
+
+	command := &discordgo.ApplicationCommand{}
+	if err := json.NewDecoder(r.Body).Decode(command); err != nil {
+		writeJSON(w, http.StatusBadRequest, &discordgo.APIErrorMessage{Message: err.Error()})
+		return
+	}
+	command.ID = id
+	command.GuildID = existing.GuildID
+	command.ApplicationID = existing.ApplicationID
+
+	b.commands[id] = command
+	writeJSON(w, http.StatusOK, command)
+}
+
+func (b *Backend) deleteCommand(w http.ResponseWriter, id string) {
+	if _, ok := b.commands[id]; !ok {
+		writeFound(w, nil, false)
+		return
+	}
+	delete(b.commands, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pattern replaces the IDs and tokens in a split path with wildcards, so it can be matched against a route.
+func pattern(path []string) string {
+	p := make([]string, len(path))
+	for i, segment := range path {
+		switch segment {
+		case "interactions", "callback", "webhooks", "messages", "guilds", "roles", "members", "channels", "users", "applications", "commands", "typing", "reactions":
+			p[i] = segment
+		default:
+			p[i] = "*"
+		}
+	}
+	return strings.Join(p, "/")
+}
+
+// writeFound writes v as JSON, or a 404 Not Found error when it was not found.
+func writeFound(w http.ResponseWriter, v interface{}, found bool) {
+	if !found {
+		writeJSON(w, http.StatusNotFound, &discordgo.APIErrorMessage{Code: 10000, Message: "harmoniatest: not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// rewriteTransport sends every request to the target instead of its original host.
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	r.Host = ""
+	return http.DefaultTransport.RoundTrip(r)
+}
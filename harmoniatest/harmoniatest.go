@@ -0,0 +1,376 @@
+// Package harmoniatest provides an offline test harness for Harmonia command and component handlers.
+//
+// A Harness wraps a Harmonia whose REST requests are answered by a fake Discord API, the Backend.
+// Interactions are simulated by dispatching them through Harmonia.Dispatch, after which every response, follow-up, edit and
+// component handler registration it caused is recorded for assertions.
+package harmoniatest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Moonlington/harmonia"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// AppID is the application and user ID of the bot in a Harness.
+	AppID = "100"
+	// ChannelID is the ID of the channel simulated Interactions are sent from by default.
+	ChannelID = "200"
+)
+
+// A Harness is a Harmonia connected to a Backend instead of Discord, used to simulate Interactions.
+type Harness struct {
+	*harmonia.Harmonia
+	Backend *Backend
+
+	// ChannelID is the ID of the channel simulated Interactions are sent from.
+	ChannelID string
+	// AppPermissions are the permissions the bot has in the channel of simulated Interactions.
+	AppPermissions int64
+
+	mu     sync.Mutex
+	nextID int
+}
+
+// New returns a Harness with a fresh Harmonia and Backend. The Backend is closed when the test finishes.
+func New(tb testing.TB) *Harness {
+	tb.Helper()
+
+	b := NewBackend()
+	tb.Cleanup(b.Close)
+
+	h, err := harmonia.New("harmoniatest")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	bot := &discordgo.User{ID: AppID, Username: "harmoniatest", Bot: true}
+	b.SetCurrentUser(bot)
+
+	h.Client = b.Client()
+	h.State.User = bot
+
+	return &Harness{
+		Harmonia:       h,
+		Backend:        b,
+		ChannelID:      ChannelID,
+		AppPermissions: discordgo.PermissionAll,
+		nextID:         5000,
+	}
+}
+
+// A Recording contains everything Harmonia did in response to a simulated Interaction.
+type Recording struct {
+	// Interaction is the Interaction that was simulated, it is nil when a message or event was simulated.
+	Interaction *discordgo.Interaction
+	// TextMessage is the message that was simulated, it is nil when an Interaction was simulated.
+	TextMessage *discordgo.Message
+	// Calls are the calls made to the Backend for the Interaction, in the order they were made.
+	Calls []*Call
+	// Components are the customIDs of the component handlers that were added while handling the Interaction.
+	Components []string
+}
+
+// Of returns the calls of the given kind.
+func (r *Recording) Of(kind CallKind) []*Call {
+	calls := make([]*Call, 0)
+	for _, call := range r.Calls {
+		if call.Kind == kind {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Response returns the initial response to the Interaction, or nil if there was none.
+func (r *Recording) Response() *Call {
+	if responses := r.Of(CallResponse); len(responses) > 0 {
+		return responses[0]
+	}
+	return nil
+}
+
+// Simulate dispatches an application command Interaction for the command with the given path, such as "admin ban", invoked by author.
+// Members are invoked in the Guild of the Author, which is served by the Backend if it was not already. Users are invoked in DMs.
+// Simulate returns once all handlers started by the Interaction have returned.
+func (hs *Harness) Simulate(path string, options []*discordgo.ApplicationCommandInteractionDataOption, author *harmonia.Author) *Recording {
+	return hs.simulate(discordgo.InteractionApplicationCommand, path, options, author)
+}
+
+// SimulateAutocomplete dispatches an autocomplete Interaction for the command with the given path, like Simulate.
+// The option that is being typed should be marked as Focused, the choices that were suggested for it are in the Choices of the Response.
+func (hs *Harness) SimulateAutocomplete(path string, options []*discordgo.ApplicationCommandInteractionDataOption, author *harmonia.Author) *Recording {
+	return hs.simulate(discordgo.InteractionApplicationCommandAutocomplete, path, options, author)
+}
+
+func (hs *Harness) simulate(interactionType discordgo.InteractionType, path string, options []*discordgo.ApplicationCommandInteractionDataOption, author *harmonia.Author) *Recording {
+	names := strings.Fields(path)
+	for depth := len(names) - 1; depth > 0; depth-- {
+		t := discordgo.ApplicationCommandOptionSubCommand
+		if depth < len(names)-1 {
+			t = discordgo.ApplicationCommandOptionSubCommandGroup
+		}
+		options = []*discordgo.ApplicationCommandInteractionDataOption{{Name: names[depth], Type: t, Options: options}}
+	}
+
+	var name string
+	if len(names) > 0 {
+		name = names[0]
+	}
+
+	i := hs.newInteraction(interactionType, author)
+	i.Data = discordgo.ApplicationCommandInteractionData{
+		ID:          hs.newID(),
+		Name:        name,
+		CommandType: discordgo.ChatApplicationCommand,
+		Options:     options,
+	}
+	return hs.dispatch(i)
+}
+
+// SimulateComponent dispatches a component Interaction, as if author clicked the component with the given customID on the message with the given ID.
+// Values are only used for select menus.
+func (hs *Harness) SimulateComponent(messageID, customID string, values []string, author *harmonia.Author) *Recording {
+	i := hs.newInteraction(discordgo.InteractionMessageComponent, author)
+	i.Message = &discordgo.Message{ID: messageID, ChannelID: i.ChannelID, GuildID: i.GuildID}
+	if message := hs.Backend.Message(messageID); message != nil {
+		i.Message.Content = message.Content
+		i.Message.Components = message.Components
+	}
+	i.Data = discordgo.MessageComponentInteractionData{
+		CustomID: customID,
+		Values:   values,
+	}
+	hs.Backend.setSource(i.Token, messageID)
+	return hs.dispatch(i)
+}
+
+// SimulateMessage dispatches a message with the given content sent by author, which invokes a TextCommand if it starts with a prefix.
+// The permissions of the bot are computed from the roles of the Guild, the bot is added to it as a member without roles if needed.
+// SimulateMessage returns once all handlers started by the message have returned. All calls made to the Backend in the meantime are recorded.
+func (hs *Harness) SimulateMessage(content string, author *harmonia.Author) *Recording {
+	m := &discordgo.Message{
+		ID:        hs.newID(),
+		ChannelID: hs.ChannelID,
+		Content:   content,
+		Author:    author.User,
+	}
+
+	if author.IsMember && author.Guild != nil {
+		hs.Backend.addMissingGuild(author.Guild)
+		hs.Backend.addMissingMember(author.Guild.ID, &discordgo.Member{User: hs.State.User})
+		hs.ensureChannel(author.Guild.ID)
+
+		m.GuildID = author.Guild.ID
+		m.Member = &discordgo.Member{
+			Nick:         author.Nick,
+			Roles:        roleIDs(author.Roles),
+			JoinedAt:     author.JoinedAt,
+			Deaf:         author.Deaf,
+			Mute:         author.Mute,
+			PremiumSince: author.PremiumSince,
+		}
+	} else {
+		hs.ensureChannel("")
+	}
+
+	before := len(hs.Backend.Calls())
+	hs.DispatchMessage(&discordgo.MessageCreate{Message: m})
+	hs.Wait()
+
+	return &Recording{TextMessage: m, Calls: hs.Backend.Calls()[before:], Components: make([]string, 0)}
+}
+
+// SimulateEvent dispatches a gateway event through Harmonia.DispatchEvent, such as a *discordgo.GuildMemberAdd.
+// SimulateEvent returns once all listeners started by the event have returned. All calls made to the Backend in the meantime are recorded.
+func (hs *Harness) SimulateEvent(event interface{}) *Recording {
+	before := len(hs.Backend.Calls())
+	hs.DispatchEvent(event)
+	hs.Wait()
+
+	return &Recording{Calls: hs.Backend.Calls()[before:], Components: make([]string, 0)}
+}
+
+// SimulateReactionAdd dispatches a reaction with the given emoji added by author to a message, which calls its reaction handlers and the listeners for it.
+// The emoji is the unicode emoji, or "name:id" for a custom emoji. Members react in the Guild of the Author, users in DMs.
+// SimulateReactionAdd returns once all handlers started by the reaction have returned. All calls made to the Backend in the meantime are recorded.
+func (hs *Harness) SimulateReactionAdd(messageID, emoji string, author *harmonia.Author) *Recording {
+	reaction, member := hs.newReaction(messageID, emoji, author)
+	return hs.SimulateEvent(&discordgo.MessageReactionAdd{MessageReaction: reaction, Member: member})
+}
+
+// SimulateReactionRemove does the same as SimulateReactionAdd, for a reaction that author removed.
+func (hs *Harness) SimulateReactionRemove(messageID, emoji string, author *harmonia.Author) *Recording {
+	reaction, _ := hs.newReaction(messageID, emoji, author)
+	return hs.SimulateEvent(&discordgo.MessageReactionRemove{MessageReaction: reaction})
+}
+
+func (hs *Harness) newReaction(messageID, emoji string, author *harmonia.Author) (*discordgo.MessageReaction, *discordgo.Member) {
+	name, id, _ := strings.Cut(emoji, ":")
+	reaction := &discordgo.MessageReaction{
+		UserID:    author.ID,
+		MessageID: messageID,
+		ChannelID: hs.ChannelID,
+		Emoji:     discordgo.Emoji{Name: name, ID: id},
+	}
+
+	if !author.IsMember || author.Guild == nil {
+		hs.ensureChannel("")
+		return reaction, nil
+	}
+
+	hs.Backend.addMissingGuild(author.Guild)
+	hs.Backend.addMissingMember(author.Guild.ID, &discordgo.Member{User: hs.State.User})
+	hs.ensureChannel(author.Guild.ID)
+
+	reaction.GuildID = author.Guild.ID
+	return reaction, &discordgo.Member{
+		GuildID:      author.Guild.ID,
+		User:         author.User,
+		Nick:         author.Nick,
+		Roles:        roleIDs(author.Roles),
+		JoinedAt:     author.JoinedAt,
+		Deaf:         author.Deaf,
+		Mute:         author.Mute,
+		PremiumSince: author.PremiumSince,
+	}
+}
+
+func (hs *Harness) newID() string {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.nextID++
+	return fmt.Sprint(hs.nextID)
+}
+
+func (hs *Harness) newInteraction(t discordgo.InteractionType, author *harmonia.Author) *discordgo.Interaction {
+	id := hs.newID()
+	i := &discordgo.Interaction{
+		ID:             id,
+		AppID:          AppID,
+		Type:           t,
+		ChannelID:      hs.ChannelID,
+		AppPermissions: hs.AppPermissions,
+		Token:          "token-" + id,
+		Locale:         discordgo.EnglishUS,
+		Version:        1,
+	}
+
+	if !author.IsMember || author.Guild == nil {
+		i.User = author.User
+		hs.ensureChannel("")
+		return i
+	}
+
+	hs.Backend.addMissingGuild(author.Guild)
+	hs.ensureChannel(author.Guild.ID)
+
+	i.GuildID = author.Guild.ID
+	i.Member = &discordgo.Member{
+		GuildID:      author.Guild.ID,
+		User:         author.User,
+		Nick:         author.Nick,
+		Roles:        roleIDs(author.Roles),
+		JoinedAt:     author.JoinedAt,
+		Deaf:         author.Deaf,
+		Mute:         author.Mute,
+		PremiumSince: author.PremiumSince,
+		Permissions:  author.Permissions(nil),
+	}
+	return i
+}
+
+func roleIDs(roles []*discordgo.Role) []string {
+	ids := make([]string, len(roles))
+	for n, role := range roles {
+		ids[n] = role.ID
+	}
+	return ids
+}
+
+func (hs *Harness) ensureChannel(guildID string) {
+	channelType := discordgo.ChannelTypeGuildText
+	if guildID == "" {
+		channelType = discordgo.ChannelTypeDM
+	}
+	hs.Backend.addMissingChannel(&discordgo.Channel{ID: hs.ChannelID, GuildID: guildID, Type: channelType})
+}
+
+func (hs *Harness) dispatch(i *discordgo.Interaction) *Recording {
+	before := hs.componentIDs()
+
+	hs.Dispatch(&discordgo.InteractionCreate{Interaction: i})
+	hs.Wait()
+
+	r := &Recording{Interaction: i, Components: make([]string, 0)}
+	for id := range hs.componentIDs() {
+		if !before[id] {
+			r.Components = append(r.Components, id)
+		}
+	}
+	sort.Strings(r.Components)
+
+	for _, call := range hs.Backend.Calls() {
+		if call.Token == i.Token {
+			r.Calls = append(r.Calls, call)
+		}
+	}
+	return r
+}
+
+func (hs *Harness) componentIDs() map[string]bool {
+	ids := make(map[string]bool)
+	for _, id := range hs.ComponentHandlerIDs() {
+		ids[id] = true
+	}
+	return ids
+}
+
+// Member returns an Author that is a member of the guild with the given roles, for use with Simulate.
+func Member(guild *discordgo.Guild, user *discordgo.User, roles ...*discordgo.Role) *harmonia.Author {
+	return &harmonia.Author{User: user, IsMember: true, Guild: guild, Nick: user.GlobalName, Roles: roles}
+}
+
+// User returns an Author that is not a member of any guild, simulated Interactions from it happen in DMs.
+func User(user *discordgo.User) *harmonia.Author {
+	return harmonia.AuthorFromUser(user)
+}
+
+// StringOption returns a string option with the given name and value, for use with Simulate.
+func StringOption(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+}
+
+// IntegerOption returns an integer option with the given name and value, for use with Simulate.
+func IntegerOption(name string, value int64) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionInteger, Value: float64(value)}
+}
+
+// NumberOption returns a number option with the given name and value, for use with Simulate.
+func NumberOption(name string, value float64) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionNumber, Value: value}
+}
+
+// BooleanOption returns a boolean option with the given name and value, for use with Simulate.
+func BooleanOption(name string, value bool) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionBoolean, Value: value}
+}
+
+// UserOption returns a user option with the given name and user ID, for use with Simulate.
+func UserOption(name, userID string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionUser, Value: userID}
+}
+
+// ChannelOption returns a channel option with the given name and channel ID, for use with Simulate.
+func ChannelOption(name, channelID string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionChannel, Value: channelID}
+}
+
+// RoleOption returns a role option with the given name and role ID, for use with Simulate.
+func RoleOption(name, roleID string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionRole, Value: roleID}
+}
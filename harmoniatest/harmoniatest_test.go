@@ -0,0 +1,606 @@
+package harmoniatest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Moonlington/harmonia"
+	"github.com/Moonlington/harmonia/harmoniatest"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	guild     = &discordgo.Guild{ID: "300", OwnerID: "1", Roles: []*discordgo.Role{{ID: "300"}, moderator}}
+	moderator = &discordgo.Role{ID: "301", Position: 1, Permissions: discordgo.PermissionBanMembers}
+	alice     = &discordgo.User{ID: "2", Username: "alice"}
+	bob       = &discordgo.User{ID: "3", Username: "bob"}
+)
+
+func TestSimulateSlashCommand(t *testing.T) {
+	hs := harmoniatest.New(t)
+	hs.AddCommand(harmonia.NewSlashCommand("echo").
+		WithDescription("Echoes text").
+		WithOptions(harmonia.NewOption("text", discordgo.ApplicationCommandOptionString).WithDescription("The text to echo")).
+		WithCommand(func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+			author, err := i.GetAuthor()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			h.Respond(i, fmt.Sprintf("%v says %v", author.Username, i.GetOption("text").StringValue()))
+		}))
+
+	r := hs.Simulate("echo", []*discordgo.ApplicationCommandInteractionDataOption{
+		harmoniatest.StringOption("text", "hello"),
+	}, harmoniatest.Member(guild, alice))
+
+	assert.Len(t, r.Calls, 1)
+	assert.Equal(t, discordgo.InteractionResponseChannelMessageWithSource, r.Response().ResponseType)
+	assert.Equal(t, "alice says hello", r.Response().Message.Content)
+
+	r = hs.Simulate("echo", []*discordgo.ApplicationCommandInteractionDataOption{
+		harmoniatest.StringOption("text", "hi"),
+	}, harmoniatest.User(bob))
+	assert.Equal(t, "bob says hi", r.Response().Message.Content)
+}
+
+func TestSimulateGroupSlashCommandWithGuards(t *testing.T) {
+	hs := harmoniatest.New(t)
+	var invoked *harmonia.Invocation
+	ban := harmonia.NewSlashCommand("ban").
+		WithDescription("Bans a user").
+		WithOptions(harmonia.NewOption("user", discordgo.ApplicationCommandOptionUser).WithDescription("The user to ban")).
+		WithGuards(harmonia.RequirePermissions(discordgo.PermissionBanMembers)).
+		WithCommand(func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+			invoked = i
+			h.Respond(i, "banned <@"+i.GetOption("user").Value.(string)+">")
+		})
+	hs.AddCommand(harmonia.NewGroupSlashCommand("admin").WithDescription("Moderation").WithSubCommands(ban))
+
+	r := hs.Simulate("admin ban", []*discordgo.ApplicationCommandInteractionDataOption{
+		harmoniatest.UserOption("user", bob.ID),
+	}, harmoniatest.Member(guild, alice, moderator))
+	assert.Equal(t, "banned <@3>", r.Response().Message.Content)
+	assert.Equal(t, []string{"admin", "ban"}, invoked.CommandPath)
+	assert.Equal(t, ban, invoked.Command)
+	assert.Equal(t, r.Interaction.ApplicationCommandData().ID, invoked.CommandID)
+
+	r = hs.Simulate("admin ban", []*discordgo.ApplicationCommandInteractionDataOption{
+		harmoniatest.UserOption("user", alice.ID),
+	}, harmoniatest.Member(guild, bob))
+	assert.Equal(t, "you are missing the following permissions: Ban Members", r.Response().Message.Content)
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, r.Response().Message.Flags)
+}
+
+func TestSimulateFollowups(t *testing.T) {
+	hs := harmoniatest.New(t)
+	hs.AddCommand(harmonia.NewSlashCommand("slow").
+		WithDescription("Takes a while").
+		WithCommand(func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+			h.DeferResponse(i)
+			f, err := h.Followup(i, "working on it")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			h.EditFollowup(f, "done")
+			h.EphemeralFollowup(i, "only for you")
+		}))
+
+	r := hs.Simulate("slow", nil, harmoniatest.Member(guild, alice))
+	assert.Len(t, r.Calls, 4)
+	assert.Equal(t, discordgo.InteractionResponseDeferredChannelMessageWithSource, r.Response().ResponseType)
+
+	followups := r.Of(harmoniatest.CallFollowup)
+	assert.Len(t, followups, 2)
+	assert.Equal(t, "working on it", followups[0].Message.Content)
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, followups[1].Message.Flags)
+
+	edits := r.Of(harmoniatest.CallEdit)
+	assert.Len(t, edits, 1)
+	assert.Equal(t, followups[0].Message.ID, edits[0].Message.ID)
+	assert.Equal(t, "done", hs.Backend.Message(followups[0].Message.ID).Content)
+}
+
+func TestSimulateComponents(t *testing.T) {
+	hs := harmoniatest.New(t)
+	count := 0
+	hs.AddCommand(harmonia.NewSlashCommand("counter").
+		WithDescription("Counts clicks").
+		WithCommand(func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+			msg, err := h.RespondWithComponents(i, "0", [][]discordgo.MessageComponent{{
+				discordgo.Button{Label: "+1", CustomID: "increase"},
+			}})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			h.AddComponentHandlerToInteractionMessage(msg, "increase", func(h *harmonia.Harmonia, ci *harmonia.Invocation) {
+				count++
+				h.RespondComplex(ci, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseUpdateMessage,
+					Data: &discordgo.InteractionResponseData{Content: fmt.Sprint(count)},
+				})
+			})
+		}))
+
+	r := hs.Simulate("counter", nil, harmoniatest.Member(guild, alice))
+	messageID := r.Response().Message.ID
+	assert.Equal(t, []string{messageID + "-increase"}, r.Components)
+	assert.Len(t, hs.Backend.Message(messageID).Components, 1)
+
+	r = hs.SimulateComponent(messageID, "increase", nil, harmoniatest.Member(guild, bob))
+	assert.Equal(t, discordgo.InteractionResponseUpdateMessage, r.Response().ResponseType)
+	assert.Equal(t, messageID, r.Response().Message.ID)
+	assert.Equal(t, "1", hs.Backend.Message(messageID).Content)
+
+	r = hs.SimulateComponent("unknown", "increase", nil, harmoniatest.Member(guild, bob))
+	assert.Len(t, r.Calls, 1)
+	assert.Equal(t, "This button has expired.", r.Response().Message.Content)
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, r.Response().Message.Flags)
+}
+
+func TestSimulateUnknownCommand(t *testing.T) {
+	hs := harmoniatest.New(t)
+	hs.OnUnknownCommand = func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+		h.Respond(i, fmt.Sprintf("'%v' was removed", i.CommandPath[0]))
+	}
+
+	r := hs.Simulate("removed", nil, harmoniatest.Member(guild, alice))
+	assert.Equal(t, "'removed' was removed", r.Response().Message.Content)
+}
+
+func TestRegisterCommands(t *testing.T) {
+	hs := harmoniatest.New(t)
+	hs.State.User = nil
+
+	ping := harmonia.NewSlashCommand("ping").WithDescription("Ping!")
+	hs.AddCommand(ping)
+	hs.AddCommand(harmonia.NewUserCommand("Inspect").WithGuildID(guild.ID))
+
+	assert.Nil(t, hs.RegisterCommands(context.Background()))
+
+	assert.Len(t, hs.Backend.Commands(), 2)
+
+	assert.Nil(t, hs.RemoveCommand("ping"))
+	assert.Len(t, hs.Backend.Commands(), 1)
+}
+
+// greeter is a Module with a command, a component handler and an event listener.
+type greeter struct {
+	greeted  []string
+	shutdown bool
+}
+
+func (g *greeter) Name() string { return "greeter" }
+
+func (g *greeter) Commands() []harmonia.CommandHandler {
+	return []harmonia.CommandHandler{harmonia.NewSlashCommand("hello").WithDescription("Say hello").
+		WithCommand(func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+			h.Respond(i, "hello!")
+		})}
+}
+
+func (g *greeter) ComponentHandlers() map[string]harmonia.CommandFunc {
+	return map[string]harmonia.CommandFunc{"wave": func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+		h.Respond(i, "👋")
+	}}
+}
+
+func (g *greeter) Events() []harmonia.Listener {
+	return []harmonia.Listener{func(h *harmonia.Harmonia) func() {
+		return h.OnMemberJoin(func(h *harmonia.Harmonia, e *harmonia.MemberJoin) {
+			g.greeted = append(g.greeted, e.User.ID)
+		})
+	}}
+}
+
+func (g *greeter) Init(h *harmonia.Harmonia) error     { return nil }
+func (g *greeter) Shutdown(h *harmonia.Harmonia) error { g.shutdown = true; return nil }
+
+func TestModules(t *testing.T) {
+	hs := harmoniatest.New(t)
+	assert.Nil(t, hs.RegisterCommands(context.Background()))
+
+	g := &greeter{}
+	assert.Nil(t, hs.LoadModule(g))
+	assert.Error(t, hs.LoadModule(g))
+	assert.Equal(t, []harmonia.Module{g}, hs.Modules())
+	assert.Len(t, hs.Backend.Commands(), 1)
+
+	assert.Equal(t, "hello!", hs.Simulate("hello", nil, harmoniatest.Member(guild, alice)).Response().Message.Content)
+	assert.Equal(t, "👋", hs.SimulateComponent("1", "wave", nil, harmoniatest.Member(guild, alice)).Response().Message.Content)
+	hs.SimulateEvent(&discordgo.GuildMemberAdd{Member: &discordgo.Member{GuildID: guild.ID, User: bob}})
+	assert.Equal(t, []string{bob.ID}, g.greeted)
+
+	assert.Nil(t, hs.UnloadModule("greeter"))
+	assert.True(t, g.shutdown)
+	assert.Empty(t, hs.Modules())
+	assert.Empty(t, hs.Backend.Commands())
+	assert.Equal(t, "This command is no longer available.", hs.Simulate("hello", nil, harmoniatest.Member(guild, alice)).Response().Message.Content)
+	hs.SimulateEvent(&discordgo.GuildMemberAdd{Member: &discordgo.Member{GuildID: guild.ID, User: alice}})
+	assert.Equal(t, []string{bob.ID}, g.greeted)
+
+	// A Module is not loaded at all when one of its handlers already exists.
+	hs.AddComponentHandler("wave", func(h *harmonia.Harmonia, i *harmonia.Invocation) {})
+	assert.EqualError(t, hs.LoadModule(g), "module 'greeter' can not be loaded: CustomID 'wave' already exists")
+	assert.Empty(t, hs.Backend.Commands())
+	assert.NotContains(t, hs.Commands, "hello")
+}
+
+// dependent is a Module that loads the Module it depends on from Init, and fails to initialise when err is set.
+type dependent struct {
+	greeter *greeter
+	modules []harmonia.Module
+	err     error
+}
+
+func (d *dependent) Name() string { return "dependent" }
+
+func (d *dependent) Commands() []harmonia.CommandHandler {
+	return []harmonia.CommandHandler{harmonia.NewSlashCommand("depend").WithDescription("Depend")}
+}
+
+func (d *dependent) ComponentHandlers() map[string]harmonia.CommandFunc { return nil }
+func (d *dependent) Events() []harmonia.Listener                        { return nil }
+
+func (d *dependent) Init(h *harmonia.Harmonia) error {
+	if err := h.LoadModule(d.greeter); err != nil {
+		return err
+	}
+	d.modules = h.Modules()
+	if err := h.UpdateCommand(harmonia.NewSlashCommand("depend").WithDescription("Depend on the greeter")); err != nil {
+		return err
+	}
+	return d.err
+}
+
+func (d *dependent) Shutdown(h *harmonia.Harmonia) error { return h.UnloadModule("greeter") }
+
+func TestModuleInit(t *testing.T) {
+	hs := harmoniatest.New(t)
+	assert.Nil(t, hs.RegisterCommands(context.Background()))
+
+	// Init and Shutdown can load and unload other Modules and update commands without deadlocking.
+	d := &dependent{greeter: &greeter{}}
+	done := make(chan error)
+	go func() { done <- hs.LoadModule(d) }()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("LoadModule did not return")
+	}
+	assert.Equal(t, []harmonia.Module{d.greeter}, d.modules)
+	assert.Equal(t, []harmonia.Module{d, d.greeter}, hs.Modules())
+	descriptions := make(map[string]string)
+	for _, command := range hs.Backend.Commands() {
+		descriptions[command.Name] = command.Description
+	}
+	assert.Equal(t, map[string]string{"depend": "Depend on the greeter", "hello": "Say hello"}, descriptions)
+
+	go func() { done <- hs.UnloadModule("dependent") }()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("UnloadModule did not return")
+	}
+	assert.True(t, d.greeter.shutdown)
+	assert.Empty(t, hs.Modules())
+	assert.Empty(t, hs.Backend.Commands())
+
+	// A Module whose Init fails is unloaded again, including the command that Init updated.
+	d = &dependent{greeter: &greeter{}, err: errors.New("no greeting")}
+	assert.EqualError(t, hs.LoadModule(d), "module 'dependent' could not be initialised: no greeting")
+	assert.Equal(t, []harmonia.Module{d.greeter}, hs.Modules())
+	assert.NotContains(t, hs.Commands, "depend")
+	assert.Len(t, hs.Backend.Commands(), 1)
+}
+
+func TestUpdateCommand(t *testing.T) {
+	hs := harmoniatest.New(t)
+	hs.Prefix = harmonia.Prefixes(harmonia.StaticPrefix("!"))
+
+	started, release := make(chan bool), make(chan bool)
+	hs.AddHybridCommand(harmonia.NewSlashCommand("ping").WithDescription("Ping!").
+		WithCommand(func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+			started <- true
+			<-release
+			h.Respond(i, "pong")
+		}))
+	assert.Nil(t, hs.RegisterCommands(context.Background()))
+	id := hs.Backend.Commands()[0].ID
+
+	// An Invocation that is running while the command is updated finishes with the old command.
+	recordings := make(chan *harmoniatest.Recording)
+	go func() { recordings <- hs.Simulate("ping", nil, harmoniatest.Member(guild, alice)) }()
+	<-started
+
+	ping := harmonia.NewSlashCommand("ping").WithDescription("Ping, but newer").
+		WithOptions(harmonia.NewOption("loud", discordgo.ApplicationCommandOptionBoolean).WithDescription("Shout it")).
+		WithCommand(func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+			h.Respond(i, "PONG")
+		})
+	assert.Nil(t, hs.UpdateCommand(ping))
+	close(release)
+	assert.Equal(t, "pong", (<-recordings).Response().Message.Content)
+
+	commands := hs.Backend.Commands()
+	assert.Len(t, commands, 1)
+	assert.Equal(t, id, commands[0].ID)
+	assert.Equal(t, "Ping, but newer", commands[0].Description)
+	assert.Len(t, commands[0].Options, 1)
+	assert.Equal(t, "PONG", hs.Simulate("ping", nil, harmoniatest.Member(guild, alice)).Response().Message.Content)
+	assert.Equal(t, "PONG", hs.SimulateMessage("!ping", harmoniatest.Member(guild, alice)).Of(harmoniatest.CallMessage)[0].Message.Content)
+
+	// A command can be changed and updated in place.
+	ping.WithDescription("Ping again")
+	assert.Nil(t, hs.UpdateCommand(ping))
+	assert.Equal(t, "Ping again", hs.Backend.Commands()[0].Description)
+
+	assert.EqualError(t, hs.UpdateCommand(harmonia.NewUserCommand("ping")), "command 'ping' can not change its type")
+	assert.EqualError(t, hs.UpdateCommand(harmonia.NewSlashCommand("pong").WithDescription("Pong!")), "command 'pong' was not found")
+}
+
+func TestUpdateCommandWhileDispatching(t *testing.T) {
+	hs := harmoniatest.New(t)
+	hs.Prefix = harmonia.Prefixes(harmonia.StaticPrefix("!"))
+
+	ids := make(chan string, 100)
+	ping := harmonia.NewSlashCommand("ping").WithDescription("Ping!").
+		WithCommand(func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+			ids <- i.CommandID
+		})
+	hs.AddHybridCommand(ping)
+	assert.Nil(t, hs.RegisterCommands(context.Background()))
+	id := hs.Backend.Commands()[0].ID
+
+	// The command keeps its registration while it is updated in place, so it is never dispatched without its ID.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for n := 0; n < 50; n++ {
+			assert.Nil(t, hs.UpdateCommand(ping))
+		}
+	}()
+	for n := 0; n < 50; n++ {
+		hs.SimulateMessage("!ping", harmoniatest.Member(guild, alice))
+		hs.CommandTree()
+	}
+	<-done
+	hs.Wait()
+	close(ids)
+
+	assert.Len(t, ids, 50)
+	for commandID := range ids {
+		assert.Equal(t, id, commandID)
+	}
+}
+
+func TestSimulateTextCommand(t *testing.T) {
+	hs := harmoniatest.New(t)
+	hs.Prefix = harmonia.Prefixes(harmonia.StaticPrefix("!"), harmonia.MentionPrefix)
+
+	var days int64
+	var author *harmonia.Author
+	hs.AddTextCommand(harmonia.NewTextCommand("ban").
+		WithOptions(
+			harmonia.NewOption("user", discordgo.ApplicationCommandOptionUser).IsRequired(),
+			harmonia.NewOption("days", discordgo.ApplicationCommandOptionInteger),
+		).
+		WithGuards(harmonia.RequirePermissions(discordgo.PermissionBanMembers)).
+		WithCommand(func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+			days = i.GetOption("days").IntValue()
+			author, _ = i.GetAuthor()
+		}))
+
+	r := hs.SimulateMessage("!ban <@3> 7", harmoniatest.Member(guild, alice, moderator))
+	assert.Len(t, r.Calls, 0)
+	assert.Equal(t, int64(7), days)
+	assert.Equal(t, alice.ID, author.ID)
+
+	r = hs.SimulateMessage("<@"+harmoniatest.AppID+"> ban <@3> 3", harmoniatest.Member(guild, alice, moderator))
+	assert.Equal(t, int64(3), days)
+
+	r = hs.SimulateMessage("!ban <@2>", harmoniatest.Member(guild, bob))
+	assert.Len(t, r.Calls, 1)
+	assert.Equal(t, harmoniatest.CallMessage, r.Calls[0].Kind)
+	assert.Equal(t, "you are missing the following permissions: Ban Members", r.Calls[0].Message.Content)
+	assert.Equal(t, r.TextMessage.ID, r.Calls[0].Message.MessageReference.MessageID)
+
+	r = hs.SimulateMessage("!ban bob", harmoniatest.Member(guild, alice, moderator))
+	assert.Equal(t, "invalid argument 'user': not a user\nUsage: `!ban <user> [days]`", r.Calls[0].Message.Content)
+
+	r = hs.SimulateMessage("ban <@3>", harmoniatest.Member(guild, alice, moderator))
+	assert.Len(t, r.Calls, 0)
+}
+
+func TestSimulateHybridCommand(t *testing.T) {
+	hs := harmoniatest.New(t)
+	hs.Prefix = harmonia.StaticPrefix("!")
+
+	var path []string
+	assert.Nil(t, hs.AddHybridCommand(harmonia.NewGroupSlashCommand("admin").
+		WithDescription("Moderation").
+		WithSubCommands(
+			harmonia.NewSlashCommand("ban").
+				WithDescription("Bans a user").
+				WithOptions(
+					harmonia.NewOption("user", discordgo.ApplicationCommandOptionUser).WithDescription("The user to ban").IsRequired(),
+					harmonia.NewOption("reason", discordgo.ApplicationCommandOptionString).WithDescription("Why they are banned"),
+				).
+				WithCommand(func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+					path = i.CommandPath
+					h.DeferResponse(i)
+					h.EditResponse(i, fmt.Sprintf("banned <@%v> for %v", i.GetOption("user").Value, i.GetOption("reason").StringValue()))
+					h.EphemeralFollowup(i, "done")
+				}),
+		), "a"))
+	assert.NotNil(t, hs.Commands["admin"])
+
+	r := hs.Simulate("admin ban", []*discordgo.ApplicationCommandInteractionDataOption{
+		harmoniatest.UserOption("user", bob.ID),
+		harmoniatest.StringOption("reason", "spam"),
+	}, harmoniatest.Member(guild, alice, moderator))
+	assert.Equal(t, "banned <@3> for spam", r.Of(harmoniatest.CallEdit)[0].Message.Content)
+
+	r = hs.SimulateMessage(`!a ban reason:"being rude" <@3>`, harmoniatest.Member(guild, alice, moderator))
+	assert.Equal(t, harmoniatest.CallTyping, r.Calls[0].Kind)
+	messages := r.Of(harmoniatest.CallMessage)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "banned <@3> for being rude", messages[0].Message.Content)
+	assert.Equal(t, []string{"admin", "ban"}, path)
+	assert.Equal(t, r.TextMessage.ID, messages[0].Message.MessageReference.MessageID)
+	assert.Equal(t, discordgo.MessageFlags(0), messages[1].Message.Flags)
+
+	r = hs.SimulateMessage("!admin kick <@3>", harmoniatest.Member(guild, alice, moderator))
+	assert.Equal(t, "unknown subcommand 'kick'\nUsage: `!admin <ban>`", r.Calls[0].Message.Content)
+
+	r = hs.SimulateMessage("!admin ban", harmoniatest.Member(guild, alice, moderator))
+	assert.Equal(t, "missing argument 'user'\nUsage: `!admin ban <user> [reason]`", r.Calls[0].Message.Content)
+}
+
+func TestSimulateDeferredHybridCommand(t *testing.T) {
+	hs := harmoniatest.New(t)
+	hs.Prefix = harmonia.StaticPrefix("!")
+
+	var err error
+	assert.Nil(t, hs.AddHybridCommand(harmonia.NewSlashCommand("slow").
+		WithDescription("Takes a while").
+		WithCommand(func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+			f, _ := h.RespondComplex(i, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredChannelMessageWithSource})
+			err = h.AddComponentHandlerToInteractionMessage(f, "cancel", func(h *harmonia.Harmonia, i *harmonia.Invocation) {})
+		})))
+
+	r := hs.SimulateMessage("!slow", harmoniatest.Member(guild, alice))
+	assert.Len(t, r.Calls, 1)
+	assert.Equal(t, harmoniatest.CallTyping, r.Calls[0].Kind)
+	assert.EqualError(t, err, "the InteractionMessage has no message", "nothing was sent to add the handler to")
+}
+
+func TestSimulateHelpCommand(t *testing.T) {
+	hs := harmoniatest.New(t)
+	hs.AddCommand(harmonia.NewHelpCommand())
+	for n := 0; n < 11; n++ {
+		hs.AddCommand(harmonia.NewSlashCommand(fmt.Sprintf("command%02d", n)).WithDescription("Does something"))
+	}
+	hs.AddCommand(harmonia.NewSlashCommand("secret").WithDescription("Shh").WithDefaultPermissions(discordgo.PermissionBanMembers))
+
+	r := hs.Simulate("help", nil, harmoniatest.Member(guild, bob))
+	embed := r.Response().Message.Embeds[0]
+	assert.Contains(t, embed.Description, "`/command00` - Does something")
+	assert.NotContains(t, embed.Description, "secret")
+	assert.Equal(t, "Page 1 of 2", embed.Footer.Text)
+	assert.Len(t, r.Components, 2)
+
+	next := hs.SimulateComponent(r.Response().Message.ID, "help-next", nil, harmoniatest.Member(guild, bob))
+	assert.Equal(t, "Page 2 of 2", next.Response().Message.Embeds[0].Footer.Text)
+
+	r = hs.Simulate("help", []*discordgo.ApplicationCommandInteractionDataOption{
+		harmoniatest.IntegerOption("page", 2),
+	}, harmoniatest.Member(guild, alice, moderator))
+	assert.Contains(t, r.Response().Message.Embeds[0].Description, "`/secret`")
+
+	r = hs.Simulate("help", []*discordgo.ApplicationCommandInteractionDataOption{
+		harmoniatest.StringOption("command", "secret"),
+	}, harmoniatest.Member(guild, bob))
+	assert.Equal(t, "There is no command named 'secret' you can use.", r.Response().Message.Content)
+
+	focused := harmoniatest.StringOption("command", "SEC")
+	focused.Focused = true
+	r = hs.SimulateAutocomplete("help", []*discordgo.ApplicationCommandInteractionDataOption{focused}, harmoniatest.Member(guild, alice, moderator))
+	assert.Equal(t, discordgo.InteractionApplicationCommandAutocompleteResult, r.Response().ResponseType)
+	assert.Len(t, r.Response().Choices, 1)
+	assert.Equal(t, "secret", r.Response().Choices[0].Value)
+}
+
+func TestSimulateEvents(t *testing.T) {
+	hs := harmoniatest.New(t)
+	hs.Backend.AddGuild(guild)
+	hs.Backend.AddChannel(&discordgo.Channel{ID: harmoniatest.ChannelID, GuildID: guild.ID})
+
+	hs.OnMemberJoin(func(h *harmonia.Harmonia, e *harmonia.MemberJoin) {
+		h.ChannelMessageSend(harmoniatest.ChannelID, fmt.Sprintf("welcome %v, you have %v roles", e.Author.Username, len(e.Author.Roles)))
+	})
+	removeReaction := hs.OnReactionAdd(func(h *harmonia.Harmonia, e *harmonia.ReactionAdd) {
+		h.ChannelMessageSend(e.ChannelID, e.Author.Username+" reacted with "+e.Emoji.Name)
+	}, harmonia.RequirePermissions(discordgo.PermissionBanMembers))
+	hs.OnMessageCreate(func(h *harmonia.Harmonia, e *harmonia.MessageCreate) {
+		panic("oops")
+	})
+
+	r := hs.SimulateEvent(&discordgo.GuildMemberAdd{Member: &discordgo.Member{GuildID: guild.ID, User: alice, Roles: []string{moderator.ID}}})
+	assert.Len(t, r.Calls, 1)
+	assert.Equal(t, "welcome alice, you have 1 roles", r.Calls[0].Message.Content)
+
+	reaction := func(user *discordgo.User, roles ...string) *discordgo.MessageReactionAdd {
+		return &discordgo.MessageReactionAdd{
+			MessageReaction: &discordgo.MessageReaction{UserID: user.ID, MessageID: "400", ChannelID: harmoniatest.ChannelID, GuildID: guild.ID, Emoji: discordgo.Emoji{Name: "👍"}},
+			Member:          &discordgo.Member{User: user, Roles: roles},
+		}
+	}
+	r = hs.SimulateEvent(reaction(alice, moderator.ID))
+	assert.Len(t, r.Calls, 1)
+	assert.Equal(t, "alice reacted with 👍", r.Calls[0].Message.Content)
+
+	r = hs.SimulateEvent(reaction(bob))
+	assert.Len(t, r.Calls, 0, "the guard fails without replying")
+
+	r = hs.SimulateEvent(reaction(hs.State.User, moderator.ID))
+	assert.Len(t, r.Calls, 0, "events caused by the bot are ignored")
+
+	removeReaction()
+	r = hs.SimulateEvent(reaction(alice, moderator.ID))
+	assert.Len(t, r.Calls, 0)
+
+	r = hs.SimulateEvent(&discordgo.MessageCreate{Message: &discordgo.Message{ChannelID: harmoniatest.ChannelID, Author: bob}})
+	assert.Len(t, r.Calls, 0, "the panic is recovered")
+}
+
+func TestSimulateReactions(t *testing.T) {
+	hs := harmoniatest.New(t)
+	hs.AddCommand(harmonia.NewSlashCommand("vote").WithDescription("Starts a vote").WithCommand(func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+		msg, _ := h.Respond(i, "vote with 👍")
+		h.AddReactionHandlerToInteractionMessage(msg, "👍", 0, func(h *harmonia.Harmonia, r *harmonia.Reaction) {
+			if r.Added {
+				h.Followup(i, r.Author.Username+" voted")
+			} else {
+				h.Followup(i, r.Author.ID+" unvoted")
+			}
+		})
+	}))
+
+	messageID := hs.Simulate("vote", nil, harmoniatest.Member(guild, alice)).Response().Message.ID
+	hs.SimulateReactionAdd(messageID, "👎", harmoniatest.Member(guild, bob))
+	hs.SimulateReactionAdd(messageID, "👍", harmoniatest.Member(guild, bob))
+	hs.SimulateReactionRemove(messageID, "👍", harmoniatest.Member(guild, bob))
+
+	followups := hs.Backend.Calls()[len(hs.Backend.Calls())-2:]
+	assert.Equal(t, "bob voted", followups[0].Message.Content)
+	assert.Equal(t, "3 unvoted", followups[1].Message.Content)
+}
+
+func TestSimulateEventWithoutMember(t *testing.T) {
+	cleaners := &discordgo.Role{ID: "311", Position: 1, Permissions: discordgo.PermissionManageMessages}
+	guild := &discordgo.Guild{ID: "310", OwnerID: "1", Roles: []*discordgo.Role{{ID: "310"}, cleaners}}
+
+	hs := harmoniatest.New(t)
+	hs.OnReactionRemove(func(h *harmonia.Harmonia, e *harmonia.ReactionRemove) {
+		h.ChannelMessageSend(e.ChannelID, e.UserID+" removed "+e.Emoji.Name)
+	}, harmonia.RequireBotPermissions(discordgo.PermissionManageMessages))
+
+	// A removed reaction has no member, yet it is in a guild, so the permissions of the bot there are checked.
+	r := hs.SimulateReactionRemove("400", "👍", harmoniatest.Member(guild, alice))
+	assert.Len(t, r.Calls, 0, "the bot can not manage messages")
+
+	hs.Backend.AddMember(guild.ID, &discordgo.Member{User: hs.State.User, Roles: []string{cleaners.ID}})
+	r = hs.SimulateReactionRemove("400", "👍", harmoniatest.Member(guild, alice))
+	assert.Len(t, r.Calls, 1)
+	assert.Equal(t, "2 removed 👍", r.Calls[0].Message.Content)
+}
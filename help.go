@@ -0,0 +1,195 @@
+package harmonia
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// helpPageSize is the number of commands listed on a page of the help command.
+const helpPageSize = 10
+
+// helpTimeout is how long the buttons of the help command turn the page, which is as long as the message of an Interaction can be edited.
+const helpTimeout = 15 * time.Minute
+
+// NewHelpCommand returns a SlashCommand named "help" that lists the commands the invoker can use, or explains one of them.
+// The list is paginated with buttons, and the name of the command to explain is autocompleted. It can be added with AddCommand or AddHybridCommand.
+func NewHelpCommand() *SlashCommand {
+	return NewSlashCommand("help").
+		WithDescription("Shows the commands you can use").
+		WithDMPermission(true).
+		WithOptions(
+			NewOption("command", discordgo.ApplicationCommandOptionString).
+				WithDescription("The command to explain").
+				WithAutocomplete(helpAutocomplete),
+			NewOption("page", discordgo.ApplicationCommandOptionInteger).
+				WithDescription("The page of commands to show"),
+		).
+		WithCommand(help)
+}
+
+func help(h *Harmonia, i *Invocation) {
+	if option := i.GetOption("command"); option != nil {
+		helpCommand(h, i, option.StringValue())
+		return
+	}
+
+	commands := make([]*CommandNode, 0)
+	for _, n := range h.CommandTree().Leaves() {
+		if n.Allowed(h, i) {
+			commands = append(commands, n)
+		}
+	}
+
+	pages := (len(commands) + helpPageSize - 1) / helpPageSize
+	if pages == 0 {
+		pages = 1
+	}
+
+	page := 0
+	if option := i.GetOption("page"); option != nil {
+		page = int(option.IntValue()) - 1
+	}
+	page = clampPage(page, pages)
+
+	m, err := h.RespondComplex(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{helpPage(commands, page, pages)},
+			Components: helpButtons(page, pages),
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil || pages == 1 {
+		return
+	}
+
+	// Component handlers run in their own goroutine, so clicks that come in at once take turns with the page.
+	var mu sync.Mutex
+	turn := func(delta int) CommandFunc {
+		return func(h *Harmonia, ci *Invocation) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			page = clampPage(page+delta, pages)
+			h.RespondComplex(ci, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseUpdateMessage,
+				Data: &discordgo.InteractionResponseData{
+					Embeds:     []*discordgo.MessageEmbed{helpPage(commands, page, pages)},
+					Components: helpButtons(page, pages),
+				},
+			})
+		}
+	}
+	h.AddComponentHandlerToInteractionMessage(m, "help-previous", turn(-1))
+	h.AddComponentHandlerToInteractionMessage(m, "help-next", turn(1))
+
+	// The buttons can not turn the page once the Interaction has expired, so their handlers are removed by then.
+	time.AfterFunc(helpTimeout, func() {
+		h.RemoveComponentHandlerFromInteractionMessage(m, "help-previous")
+		h.RemoveComponentHandlerFromInteractionMessage(m, "help-next")
+	})
+}
+
+func helpPage(commands []*CommandNode, page, pages int) *discordgo.MessageEmbed {
+	start, end := page*helpPageSize, (page+1)*helpPageSize
+	if end > len(commands) {
+		end = len(commands)
+	}
+
+	lines := make([]string, 0, helpPageSize)
+	for _, n := range commands[start:end] {
+		line := fmt.Sprintf("`%v`", n.Usage())
+		if n.Description != "" {
+			line += " - " + n.Description
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, "There are no commands you can use here.")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "Commands",
+		Description: strings.Join(lines, "\n"),
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Page %v of %v", page+1, pages)},
+	}
+}
+
+func clampPage(page, pages int) int {
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	return page
+}
+
+func helpButtons(page, pages int) []discordgo.MessageComponent {
+	if pages == 1 {
+		return nil
+	}
+
+	return ParseComponentMatrix([][]discordgo.MessageComponent{{
+		discordgo.Button{Label: "Previous", Style: discordgo.SecondaryButton, CustomID: "help-previous", Disabled: page == 0},
+		discordgo.Button{Label: "Next", Style: discordgo.SecondaryButton, CustomID: "help-next", Disabled: page == pages-1},
+	}})
+}
+
+func helpCommand(h *Harmonia, i *Invocation, name string) {
+	n := h.CommandTree().Find(strings.Fields(name)...)
+	if n == nil || !n.Allowed(h, i) {
+		h.EphemeralRespond(i, fmt.Sprintf("There is no command named '%v' you can use.", name))
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{Title: n.Usage(), Description: n.Description}
+
+	for _, option := range n.Options {
+		value := option.Description
+		if value == "" {
+			value = "-"
+		}
+		if option.Required {
+			value += " (required)"
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: option.Name, Value: value})
+	}
+
+	subcommands := make([]string, 0, len(n.Children))
+	for _, child := range n.Children {
+		if child.Allowed(h, i) {
+			subcommands = append(subcommands, fmt.Sprintf("`%v`", child.Usage()))
+		}
+	}
+	if len(subcommands) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Subcommands", Value: strings.Join(subcommands, "\n")})
+	}
+
+	if n.DefaultPermissions != nil && *n.DefaultPermissions != 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Permissions", Value: formatPermissions(*n.DefaultPermissions)})
+	}
+
+	h.RespondComplex(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+func helpAutocomplete(h *Harmonia, i *Invocation, value string) []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0)
+	h.CommandTree().Walk(func(n *CommandNode) {
+		if strings.Contains(n.Name(), strings.ToLower(value)) && n.Allowed(h, i) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: n.Name(), Value: n.Name()})
+		}
+	})
+	return choices
+}
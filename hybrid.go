@@ -0,0 +1,182 @@
+package harmonia
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// AddHybridCommand adds a SlashCommand or GroupSlashCommand to Harmonia both as an application command and as a TextCommand with the same name and the given aliases.
+// As a TextCommand, subcommands are selected by their names and options are given as arguments in order or by name, such as "!admin ban @user reason:spam".
+// Responses to an Invocation from a message are sent as replies to it, so the same CommandFunc works for both.
+// The name and aliases are validated like those of a TextCommand, and the command is not added when they are invalid.
+func (h *Harmonia) AddHybridCommand(command CommandHandler, aliases ...string) error {
+	switch command.(type) {
+	case *SlashCommand, *GroupSlashCommand:
+	default:
+		return fmt.Errorf("command '%v' is neither a SlashCommand nor a GroupSlashCommand", command.GetName())
+	}
+
+	if err := h.AddCommand(command); err != nil {
+		return err
+	}
+
+	name := command.GetName()
+	if err := h.AddTextCommand(&TextCommand{name: name, aliases: aliases, handler: command}); err != nil {
+		h.handlersMu.Lock()
+		delete(h.Commands, name)
+		h.handlersMu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// parseHybrid converts the arguments to options for the handler of a hybrid TextCommand. The leading arguments select the subcommand,
+// after which the options are nested like they are in an Interaction, so the GroupSlashCommand can route them.
+// It returns the name of the selected subcommand and the options it has, to show its usage.
+func parseHybrid(name string, content string, args []arg, handler CommandHandler) ([]*discordgo.ApplicationCommandInteractionDataOption, string, []*Option, error) {
+	path := make([]string, 0)
+	for {
+		group, ok := handler.(*GroupSlashCommand)
+		if !ok {
+			break
+		}
+
+		names := make([]string, 0, len(group.subcommands))
+		for subname := range group.subcommands {
+			names = append(names, subname)
+		}
+		sort.Strings(names)
+		usageName := fmt.Sprintf("%v <%v>", name, strings.Join(names, "|"))
+
+		if len(args) == 0 {
+			return nil, usageName, nil, fmt.Errorf("missing subcommand")
+		}
+
+		subcommand, ok := group.subcommands[args[0].value]
+		if !ok {
+			if subcommand, ok = group.subcommands[strings.ToLower(args[0].value)]; !ok {
+				return nil, usageName, nil, fmt.Errorf("unknown subcommand '%v'", args[0].value)
+			}
+		}
+
+		handler = subcommand
+		name += " " + subcommand.GetName()
+		path = append(path, subcommand.GetName())
+		args = args[1:]
+	}
+
+	var options []*Option
+	if s, ok := handler.(*SlashCommand); ok {
+		options = s.options
+	}
+
+	parsed, err := parseArgs(content, args, options)
+	if err != nil {
+		return nil, name, options, err
+	}
+
+	for n := len(path) - 1; n >= 0; n-- {
+		t := discordgo.ApplicationCommandOptionSubCommand
+		if n < len(path)-1 {
+			t = discordgo.ApplicationCommandOptionSubCommandGroup
+		}
+		parsed = []*discordgo.ApplicationCommandInteractionDataOption{{Name: path[n], Type: t, Options: parsed}}
+	}
+	return parsed, name, options, nil
+}
+
+// textRespond replies to the message of an Invocation from a TextCommand with an InteractionResponse.
+// Messages in channels can not be ephemeral, so that flag is ignored.
+// A deferred response only shows that the bot is typing, so no InteractionMessage is returned for it, the reply is sent by EditResponse.
+func (h *Harmonia) textRespond(i *Invocation, resp *discordgo.InteractionResponse) (*InteractionMessage, error) {
+	switch resp.Type {
+	case discordgo.InteractionResponseChannelMessageWithSource:
+	case discordgo.InteractionResponseDeferredChannelMessageWithSource:
+		return nil, h.RESTClient().ChannelTyping(i.ChannelID)
+	default:
+		return nil, fmt.Errorf("response type '%v' can not be used to reply to a message", resp.Type)
+	}
+
+	data := resp.Data
+	if data == nil {
+		data = &discordgo.InteractionResponseData{}
+	}
+	return h.textReply(i, &discordgo.MessageSend{
+		Content:         data.Content,
+		Embeds:          data.Embeds,
+		TTS:             data.TTS,
+		Components:      data.Components,
+		Files:           data.Files,
+		AllowedMentions: data.AllowedMentions,
+		Flags:           data.Flags &^ discordgo.MessageFlagsEphemeral,
+	})
+}
+
+// textFollowup replies to the message of an Invocation from a TextCommand with a follow-up message.
+func (h *Harmonia) textFollowup(i *Invocation, params *discordgo.WebhookParams) (*InteractionMessage, error) {
+	return h.textReply(i, &discordgo.MessageSend{
+		Content:         params.Content,
+		Embeds:          params.Embeds,
+		TTS:             params.TTS,
+		Components:      params.Components,
+		Files:           params.Files,
+		AllowedMentions: params.AllowedMentions,
+		Flags:           params.Flags &^ discordgo.MessageFlagsEphemeral,
+	})
+}
+
+// textEditResponse edits the reply to the message of an Invocation from a TextCommand, or sends it if the response was deferred.
+func (h *Harmonia) textEditResponse(i *Invocation, edit *discordgo.WebhookEdit) (*InteractionMessage, error) {
+	if i.response == nil {
+		send := &discordgo.MessageSend{Files: edit.Files, AllowedMentions: edit.AllowedMentions}
+		if edit.Content != nil {
+			send.Content = *edit.Content
+		}
+		if edit.Components != nil {
+			send.Components = *edit.Components
+		}
+		if edit.Embeds != nil {
+			send.Embeds = *edit.Embeds
+		}
+		return h.textReply(i, send)
+	}
+
+	m, err := h.RESTClient().ChannelMessageEditComplex(messageEdit(i.response.ChannelID, i.response.ID, edit))
+	if err == nil {
+		i.response = m
+	}
+	return h.textMessage(m, i.Interaction), err
+}
+
+// textReply sends a reply to the message of an Invocation from a TextCommand. The first reply is its response.
+func (h *Harmonia) textReply(i *Invocation, send *discordgo.MessageSend) (*InteractionMessage, error) {
+	send.Reference = i.TextMessage.Reference()
+
+	m, err := h.RESTClient().ChannelMessageSendComplex(i.ChannelID, send)
+	if err == nil && i.response == nil {
+		i.response = m
+	}
+	return h.textMessage(m, i.Interaction), err
+}
+
+func (h *Harmonia) textMessage(m *discordgo.Message, i *discordgo.Interaction) *InteractionMessage {
+	f := h.interactionMessageFromMessage(m, i)
+	f.text = true
+	return f
+}
+
+func messageEdit(channelID, messageID string, edit *discordgo.WebhookEdit) *discordgo.MessageEdit {
+	return &discordgo.MessageEdit{
+		Content:         edit.Content,
+		Components:      edit.Components,
+		Embeds:          edit.Embeds,
+		AllowedMentions: edit.AllowedMentions,
+		Files:           edit.Files,
+		Attachments:     edit.Attachments,
+		ID:              messageID,
+		Channel:         channelID,
+	}
+}
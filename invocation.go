@@ -1,23 +1,194 @@
 package harmonia
 
 import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
 	"github.com/bwmarrin/discordgo"
 )
 
 // An Invocation describes an incoming Interaction.
 type Invocation struct {
 	*discordgo.Interaction
+
+	// Guild, Channel and Author are only populated when Harmonia.EagerResolve is set.
+	// Use GetGuild, GetChannel and GetAuthor instead, which resolve them lazily.
 	Guild   *discordgo.Guild
 	Channel *discordgo.Channel
 	Author  *Author
 
+	h           *Harmonia
+	guildOnce   sync.Once
+	guild       *discordgo.Guild
+	guildErr    error
+	channelOnce sync.Once
+	channel     *discordgo.Channel
+	channelErr  error
+	authorOnce  sync.Once
+	author      *Author
+	authorErr   error
+
 	options []*discordgo.ApplicationCommandInteractionDataOption
+	// guards are the guards of the groups the Invocation was routed through, which are run before those of the subcommand.
+	guards []Guard
+
+	// dispatched is when the Invocation was created, to measure the time until its first response.
+	dispatched   time.Time
+	responseOnce sync.Once
+
+	// ctx contains the span of the Invocation, see Context.
+	ctx  context.Context
+	span Span
+	// invoked is set when a handler was invoked for the Invocation, which then ends its span.
+	invoked bool
+	// event is the name of the gateway event the Invocation was created from, see DispatchEvent.
+	event string
+	// unknownComponent is set when there is no handler for the component of the Invocation.
+	unknownComponent bool
+
+	// Only when the Invocation is from a command, the names of the command and subcommands that were invoked, such as ["admin", "ban"].
+	CommandPath []string
+	// Only when the Invocation is from a command, the CommandHandler of the subcommand that was invoked.
+	// It is nil for a TextCommand that was not added with AddHybridCommand.
+	Command CommandHandler
+	// Only when the Invocation is from a command, the ID Discord registered the command with.
+	// It is empty for a TextCommand that was not added with AddHybridCommand, or when the command was not registered.
+	CommandID string
 
 	// Only when the incoming Interaction is from a SelectMenu component.
 	Values []string
 
+	// Only when the Invocation is from a TextCommand, the message that invoked it.
+	// The embedded Interaction is then filled in from the message, without a token.
+	TextMessage *discordgo.Message
+	// response is the reply that was sent to TextMessage, which EditResponse and DeleteResponse act on.
+	response *discordgo.Message
+
 	// Only when the incoming Interaction is from a UserCommand or MessageCommand.
 	targetID string
+	resolved *discordgo.ApplicationCommandInteractionDataResolved
+}
+
+// newInvocation creates an Invocation from an Interaction.
+// When Harmonia.EagerResolve is set, the dispatch methods resolve the guild, channel and author right after starting the span of the Invocation.
+func (h *Harmonia) newInvocation(interaction *discordgo.Interaction) *Invocation {
+	return &Invocation{Interaction: interaction, h: h, dispatched: time.Now()}
+}
+
+// Context returns the context of the Invocation, which contains its span when Harmonia.Tracer is set.
+// It can be passed to other calls the handler makes, so their spans become children of the Invocation.
+func (i *Invocation) Context() context.Context {
+	if i.ctx == nil {
+		return context.Background()
+	}
+	return i.ctx
+}
+
+// resolve populates the Guild, Channel and Author fields of the Invocation.
+// Those it has nothing to resolve from are skipped, such as the guild of a DM, or the channel and author of an event about a guild.
+func (i *Invocation) resolve() {
+	var err error
+	if i.GuildID != "" {
+		if i.Guild, err = i.GetGuild(); err != nil {
+			i.h.logger().Warn("could not resolve guild", i.logFields("error", err)...)
+		}
+	}
+	if i.ChannelID != "" {
+		if i.Channel, err = i.GetChannel(); err != nil {
+			i.h.logger().Warn("could not resolve channel", i.logFields("error", err)...)
+		}
+	}
+	if i.Member != nil || i.User != nil {
+		if i.Author, err = i.GetAuthor(); err != nil {
+			i.h.logger().Warn("could not resolve author", i.logFields("error", err)...)
+		}
+	}
+}
+
+// invoker returns the user that caused the Invocation, both in guilds and in DMs.
+func (i *Invocation) invoker() *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// GetGuild returns the guild the Invocation happened in, resolving it from the State cache or the Discord API the first time it is called.
+// The result is memoized, so calling it multiple times only resolves the guild once.
+func (i *Invocation) GetGuild() (*discordgo.Guild, error) {
+	if i.Guild != nil {
+		return i.Guild, nil
+	}
+
+	i.guildOnce.Do(func() {
+		if i.h == nil {
+			i.guildErr = discordgo.ErrStateNotFound
+			return
+		}
+		span := i.startSpan("harmonia.resolve_guild")
+		i.guild, i.guildErr = i.h.guild(i.GuildID)
+		endSpan(span, i.guildErr)
+	})
+	return i.guild, i.guildErr
+}
+
+// GetChannel returns the channel the Invocation happened in, resolving it from the State cache or the Discord API the first time it is called.
+// The result is memoized, so calling it multiple times only resolves the channel once.
+func (i *Invocation) GetChannel() (*discordgo.Channel, error) {
+	if i.Channel != nil {
+		return i.Channel, nil
+	}
+
+	i.channelOnce.Do(func() {
+		if i.h == nil {
+			i.channelErr = discordgo.ErrStateNotFound
+			return
+		}
+		span := i.startSpan("harmonia.resolve_channel")
+		i.channel, i.channelErr = i.h.channel(i.ChannelID)
+		endSpan(span, i.channelErr)
+	})
+	return i.channel, i.channelErr
+}
+
+// GetAuthor returns the Author of the Invocation, resolving its guild and roles the first time it is called.
+// The result is memoized, so calling it multiple times only resolves the Author once.
+func (i *Invocation) GetAuthor() (*Author, error) {
+	if i.Author != nil {
+		return i.Author, nil
+	}
+
+	i.authorOnce.Do(func() {
+		span := i.startSpan("harmonia.resolve_author")
+		defer func() { endSpan(span, i.authorErr) }()
+
+		if i.Member == nil {
+			if i.User == nil {
+				i.authorErr = errors.New("there is no author")
+				return
+			}
+			i.author = AuthorFromUser(i.User)
+			return
+		}
+
+		guild, err := i.GetGuild()
+		if err != nil {
+			i.authorErr = err
+			return
+		}
+
+		i.Member.GuildID = i.GuildID
+		i.author, i.authorErr = authorFromMember(i.h, guild, i.Member)
+	})
+	return i.author, i.authorErr
+}
+
+// setCommand records the command and subcommands the Invocation is for, following the options from the top-level command.
+func (i *Invocation) setCommand(command CommandHandler, commandID string, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	i.Command, i.CommandPath, _ = resolveSubcommand(command, options)
+	i.CommandID = commandID
 }
 
 // GetOptionMap returns a map of options passed through the Invocation.
@@ -39,23 +210,55 @@ func (i *Invocation) GetOption(name string) *discordgo.ApplicationCommandInterac
 }
 
 // TargetAuthor takes the targetID from the invocation and returns an Author struct from it.
+// The data Discord resolved for the Interaction is used when possible, before falling back to the State cache and the Discord API.
 func (i *Invocation) TargetAuthor(h *Harmonia) (*Author, error) {
-	if i.Guild != nil {
-		member, err := h.GuildMember(i.Guild.ID, i.targetID)
+	if i.GuildID != "" {
+		guild, err := i.GetGuild()
 		if err != nil {
 			return nil, err
 		}
 
-		return AuthorFromMember(h, member)
+		member, err := i.targetMember(h)
+		if err != nil {
+			return nil, err
+		}
+
+		return authorFromMember(h, guild, member)
 	}
-	user, err := h.User(i.targetID)
+
+	if i.resolved != nil {
+		if user, ok := i.resolved.Users[i.targetID]; ok {
+			return AuthorFromUser(user), nil
+		}
+	}
+	user, err := h.RESTClient().User(i.targetID)
 	if err != nil {
 		return nil, err
 	}
 	return AuthorFromUser(user), nil
 }
 
+func (i *Invocation) targetMember(h *Harmonia) (*discordgo.Member, error) {
+	if i.resolved != nil {
+		if member, ok := i.resolved.Members[i.targetID]; ok {
+			member.User = i.resolved.Users[i.targetID]
+			member.GuildID = i.GuildID
+			return member, nil
+		}
+	}
+
+	if member, err := h.state().Member(i.GuildID, i.targetID); err == nil {
+		return member, nil
+	}
+	return h.RESTClient().GuildMember(i.GuildID, i.targetID)
+}
+
 // TargetMessage takes the targetID from the invocation and returns an Message struct from it.
 func (i *Invocation) TargetMessage(h *Harmonia) (*discordgo.Message, error) {
-	return h.ChannelMessage(i.ChannelID, i.targetID)
+	if i.resolved != nil {
+		if message, ok := i.resolved.Messages[i.targetID]; ok {
+			return message, nil
+		}
+	}
+	return h.RESTClient().ChannelMessage(i.ChannelID, i.targetID)
 }
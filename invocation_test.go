@@ -0,0 +1,81 @@
+package harmonia
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvocationLazyResolution(t *testing.T) {
+	harm, err := New("token")
+	assert.Nil(t, err)
+	transport := &countingTransport{}
+	harm.Client = &http.Client{Transport: transport}
+
+	i := harm.newInvocation(&discordgo.Interaction{
+		GuildID:   "guild",
+		ChannelID: "channel",
+		Member:    &discordgo.Member{User: &discordgo.User{ID: "user"}},
+	})
+	assert.Nil(t, i.Guild)
+	assert.Nil(t, i.Channel)
+	assert.Nil(t, i.Author)
+	assert.Equal(t, int64(0), atomic.LoadInt64(&transport.requests))
+
+	guild, err := i.GetGuild()
+	assert.Nil(t, err)
+	assert.Equal(t, "guild", guild.ID)
+
+	author, err := i.GetAuthor()
+	assert.Nil(t, err)
+	assert.Equal(t, "user", author.ID)
+	assert.Equal(t, guild, author.Guild)
+
+	channel, err := i.GetChannel()
+	assert.Nil(t, err)
+	assert.Equal(t, "channel", channel.ID)
+
+	i.GetGuild()
+	i.GetChannel()
+	i.GetAuthor()
+	assert.Equal(t, int64(2), atomic.LoadInt64(&transport.requests))
+}
+
+func TestInvocationEagerResolution(t *testing.T) {
+	harm, err := New("token")
+	assert.Nil(t, err)
+	harm.Client = &http.Client{Transport: &countingTransport{}}
+	harm.EagerResolve = true
+
+	var invocation *Invocation
+	harm.AddCommand(NewSlashCommand("ping").WithDescription("Pong").WithCommand(func(h *Harmonia, i *Invocation) {
+		invocation = i
+	}))
+	harm.Dispatch(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		Data:      discordgo.ApplicationCommandInteractionData{Name: "ping"},
+		ChannelID: "channel",
+		User:      &discordgo.User{ID: "user"},
+	}})
+	harm.Wait()
+
+	assert.Nil(t, invocation.Guild)
+	assert.NotNil(t, invocation.Channel)
+	assert.Equal(t, "user", invocation.Author.ID)
+	assert.False(t, invocation.Author.IsMember)
+
+	// Events are resolved before their guards run, which skip what the event has nothing to resolve from.
+	harm.OnGuildJoin(func(h *Harmonia, e *GuildJoin) {}, func(h *Harmonia, i *Invocation) error {
+		invocation = i
+		return nil
+	})
+	harm.DispatchEvent(&discordgo.GuildCreate{Guild: &discordgo.Guild{ID: "guild"}})
+	harm.Wait()
+
+	assert.Equal(t, "guild", invocation.Guild.ID)
+	assert.Nil(t, invocation.Channel)
+	assert.Nil(t, invocation.Author)
+}
@@ -0,0 +1,91 @@
+package harmonia
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strings"
+)
+
+// A Logger receives structured events from Harmonia. Every method takes a message followed by alternating keys and values,
+// the same way log/slog does, so a *slog.Logger can be used as a Logger directly.
+//
+// Events about an Invocation carry the fields "interaction_id", "guild_id", "user_id" and "command".
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// NewStdLogger returns a Logger that writes events with at least the given level to a *log.Logger, formatted as "LEVEL msg key=value ...".
+// The levels are "debug", "info", "warn" and "error".
+func NewStdLogger(l *log.Logger, level string) Logger {
+	levels := map[string]int{"debug": 0, "info": 1, "warn": 2, "error": 3}
+	return &stdLogger{logger: l, level: levels[strings.ToLower(level)]}
+}
+
+type stdLogger struct {
+	logger *log.Logger
+	level  int
+}
+
+func (l *stdLogger) Debug(msg string, args ...any) { l.log(0, "DEBUG", msg, args) }
+func (l *stdLogger) Info(msg string, args ...any)  { l.log(1, "INFO", msg, args) }
+func (l *stdLogger) Warn(msg string, args ...any)  { l.log(2, "WARN", msg, args) }
+func (l *stdLogger) Error(msg string, args ...any) { l.log(3, "ERROR", msg, args) }
+
+func (l *stdLogger) log(level int, name, msg string, args []any) {
+	if level < l.level {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(name + " " + msg)
+	for n := 0; n+1 < len(args); n += 2 {
+		fmt.Fprintf(&b, " %v=%q", args[n], fmt.Sprint(args[n+1]))
+	}
+	l.logger.Print(b.String())
+}
+
+// nopLogger is used when Harmonia.Logger is not set.
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, args ...any) {}
+func (nopLogger) Info(msg string, args ...any)  {}
+func (nopLogger) Warn(msg string, args ...any)  {}
+func (nopLogger) Error(msg string, args ...any) {}
+
+// logger returns the Logger of Harmonia, which discards events unless Logger is set.
+func (h *Harmonia) logger() Logger {
+	if h.Logger == nil {
+		return nopLogger{}
+	}
+	return h.Logger
+}
+
+// logFields returns the fields describing the Invocation, followed by the given fields.
+func (i *Invocation) logFields(args ...any) []any {
+	var userID string
+	if user := i.invoker(); user != nil {
+		userID = user.ID
+	}
+
+	return append([]any{
+		"interaction_id", i.ID,
+		"guild_id", i.GuildID,
+		"user_id", userID,
+		"command", strings.Join(i.CommandPath, " "),
+	}, args...)
+}
+
+// recoverHandler recovers from a panic in a handler of the Invocation, logging it instead of crashing the bot.
+func (h *Harmonia) recoverHandler(i *Invocation) {
+	if r := recover(); r != nil {
+		h.logger().Error("handler panicked", i.logFields("panic", r, "stack", string(debug.Stack()))...)
+		h.metrics().HandlerError(i.handlerName(), "panic")
+		if i.span != nil {
+			i.span.RecordError(fmt.Errorf("handler panicked: %v", r))
+		}
+	}
+}
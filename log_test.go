@@ -0,0 +1,102 @@
+package harmonia
+
+import (
+	"bytes"
+	"log"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+type logEvent struct {
+	level  string
+	msg    string
+	fields map[string]any
+}
+
+// recordingLogger is a Logger that records the events logged to it.
+type recordingLogger struct {
+	mu     sync.Mutex
+	events []logEvent
+}
+
+func (l *recordingLogger) record(level, msg string, args []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fields := make(map[string]any)
+	for n := 0; n+1 < len(args); n += 2 {
+		fields[args[n].(string)] = args[n+1]
+	}
+	l.events = append(l.events, logEvent{level, msg, fields})
+}
+
+func (l *recordingLogger) Debug(msg string, args ...any) { l.record("debug", msg, args) }
+func (l *recordingLogger) Info(msg string, args ...any)  { l.record("info", msg, args) }
+func (l *recordingLogger) Warn(msg string, args ...any)  { l.record("warn", msg, args) }
+func (l *recordingLogger) Error(msg string, args ...any) { l.record("error", msg, args) }
+
+func (l *recordingLogger) find(msg string) *logEvent {
+	for _, event := range l.events {
+		if event.msg == msg {
+			return &event
+		}
+	}
+	return nil
+}
+
+func TestLogDispatch(t *testing.T) {
+	logger := &recordingLogger{}
+	harm := &Harmonia{
+		REST:              &recordingREST{},
+		Logger:            logger,
+		Commands:          make(map[string]CommandHandler),
+		ComponentHandlers: make(map[string]CommandFunc),
+	}
+	harm.AddCommand(NewGroupSlashCommand("admin").WithDescription("Moderation").WithSubCommands(NewSlashCommand("ban").WithDescription("Bans a user").WithCommand(func(h *Harmonia, i *Invocation) {
+		panic("oops")
+	})))
+
+	harm.Dispatch(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:      "interaction",
+		Type:    discordgo.InteractionApplicationCommand,
+		GuildID: "guild",
+		Member:  &discordgo.Member{User: &discordgo.User{ID: "user"}},
+		Data: discordgo.ApplicationCommandInteractionData{Name: "admin", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "ban", Type: discordgo.ApplicationCommandOptionSubCommand},
+		}},
+	}})
+	harm.Dispatch(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type: discordgo.InteractionApplicationCommand,
+		Data: discordgo.ApplicationCommandInteractionData{Name: "unknown"},
+	}})
+	harm.Dispatch(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:    discordgo.InteractionMessageComponent,
+		Message: &discordgo.Message{ID: "message"},
+		Data:    discordgo.MessageComponentInteractionData{CustomID: "expired"},
+	}})
+	harm.Wait()
+
+	dispatch := logger.find("dispatching command")
+	assert.NotNil(t, dispatch)
+	assert.Equal(t, map[string]any{"interaction_id": "interaction", "guild_id": "guild", "user_id": "user", "command": "admin ban"}, dispatch.fields)
+
+	panicked := logger.find("handler panicked")
+	assert.NotNil(t, panicked)
+	assert.Equal(t, "error", panicked.level)
+	assert.Equal(t, "oops", panicked.fields["panic"])
+	assert.Equal(t, "admin ban", panicked.fields["command"])
+
+	assert.Equal(t, "unknown", logger.find("unknown command").fields["command"])
+	assert.Equal(t, "expired", logger.find("unknown component").fields["custom_id"])
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStdLogger(log.New(&buf, "", 0), "info")
+
+	logger.Debug("hidden")
+	logger.Warn("unknown command", "command", "ban user")
+	assert.Equal(t, "WARN unknown command command=\"ban user\"\n", buf.String())
+}
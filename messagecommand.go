@@ -12,23 +12,29 @@ type MessageCommand struct {
 	guildID            string
 	dmPermission       bool
 	defaultPermissions *int64
+	guards             []Guard
 
 	commandFunc CommandFunc
 
 	registration *discordgo.ApplicationCommand
 }
 
-// NewMessageCommand returns a MessageCommand with a given name
+// NewMessageCommand returns a MessageCommand with a given name.
+// The name is validated when the MessageCommand is added with AddCommand, see MustNewMessageCommand to validate it right away.
 func NewMessageCommand(name string) *MessageCommand {
-	if name == "" {
-		log.Panic("empty command name")
-	}
-
 	return &MessageCommand{
 		name: name,
 	}
 }
 
+// MustNewMessageCommand does the same as NewMessageCommand, but panics if the name is not valid.
+func MustNewMessageCommand(name string) *MessageCommand {
+	if errs := validateContextMenuName(name); len(errs) > 0 {
+		log.Panic(errs[0])
+	}
+	return NewMessageCommand(name)
+}
+
 // WithGuildID changes the guildID of the MessageCommand and returns itself, so that it can be chained.
 func (s *MessageCommand) WithGuildID(guildID string) *MessageCommand {
 	s.guildID = guildID
@@ -53,19 +59,33 @@ func (s *MessageCommand) WithCommand(commandFunc CommandFunc) *MessageCommand {
 	return s
 }
 
+// WithGuards adds guards that have to pass before the MessageCommand is executed and returns itself, so that it can be chained.
+func (s *MessageCommand) WithGuards(guards ...Guard) *MessageCommand {
+	s.guards = append(s.guards, guards...)
+	return s
+}
+
+// WithBotPermissions makes the MessageCommand check if the bot has the given permissions before it is executed and returns itself, so that it can be chained.
+func (s *MessageCommand) WithBotPermissions(perms int64) *MessageCommand {
+	return s.WithGuards(RequireBotPermissions(perms))
+}
+
 func (s *MessageCommand) GetName() string {
 	return s.name
 }
 
 func (s *MessageCommand) Do(h *Harmonia, i *Invocation) {
-	go s.commandFunc(h, i)
+	h.invoke(i, s.guards, s.commandFunc)
 }
 
 func (s *MessageCommand) getRegistration() *discordgo.ApplicationCommand {
 	if s.registration != nil {
 		return s.registration
 	}
+	return s.buildRegistration()
+}
 
+func (s *MessageCommand) buildRegistration() *discordgo.ApplicationCommand {
 	return &discordgo.ApplicationCommand{
 		Name:                     s.name,
 		GuildID:                  s.guildID,
@@ -0,0 +1,84 @@
+package harmonia
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Metrics receives measurements about the commands and components Harmonia handles, see NewPrometheusMetrics for an implementation.
+// Handlers are named by their command path, such as "admin ban", by the CustomID of their component, or by their event, such as "event:member_join".
+// Components without a handler are named "unknown_component". The names are used as labels, so the CustomIDs of components with a handler
+// should have few distinct values, such as "confirm" rather than an ID per message or per user.
+// Methods can be called from multiple goroutines at once.
+type Metrics interface {
+	// CommandInvoked is called when a command, or the TextCommand for it, is dispatched.
+	CommandInvoked(command string)
+	// ComponentClicked is called when a component with a handler is used.
+	ComponentClicked(customID string)
+	// Responded is called with the time between dispatching an Invocation and its first response or deferral.
+	Responded(handler string, latency time.Duration)
+	// HandlerFinished is called with the time a handler ran for, once its guards have passed.
+	HandlerFinished(handler string, duration time.Duration)
+	// HandlerError is called when a handler panicked, with kind "panic", or when responding to its Invocation failed, with kind "response".
+	HandlerError(handler string, kind string)
+	// MessageHandlers is called with the number of component handlers added to InteractionMessages whenever it changes.
+	MessageHandlers(count int)
+}
+
+// nopMetrics is used when Harmonia.Metrics is not set.
+type nopMetrics struct{}
+
+func (nopMetrics) CommandInvoked(command string)                          {}
+func (nopMetrics) ComponentClicked(customID string)                       {}
+func (nopMetrics) Responded(handler string, latency time.Duration)        {}
+func (nopMetrics) HandlerFinished(handler string, duration time.Duration) {}
+func (nopMetrics) HandlerError(handler string, kind string)               {}
+func (nopMetrics) MessageHandlers(count int)                              {}
+
+// metrics returns the Metrics of Harmonia, which discards measurements unless Metrics is set.
+func (h *Harmonia) metrics() Metrics {
+	if h.Metrics == nil {
+		return nopMetrics{}
+	}
+	return h.Metrics
+}
+
+// unknownComponentName is the name components without a handler are measured by, as their CustomIDs can be stale or unique to a message.
+const unknownComponentName = "unknown_component"
+
+// handlerName returns the name the handler of the Invocation is measured by.
+func (i *Invocation) handlerName() string {
+	if i.event != "" {
+		return "event:" + i.event
+	}
+	if i.Type == discordgo.InteractionMessageComponent {
+		if i.unknownComponent {
+			return unknownComponentName
+		}
+		return i.MessageComponentData().CustomID
+	}
+	return strings.Join(i.CommandPath, " ")
+}
+
+// observeResponse measures the first response to an Invocation, or the error when responding failed.
+func (h *Harmonia) observeResponse(i *Invocation, err error) {
+	if err != nil {
+		h.observeError(i, err)
+		return
+	}
+
+	i.responseOnce.Do(func() {
+		if !i.dispatched.IsZero() {
+			h.metrics().Responded(i.handlerName(), time.Since(i.dispatched))
+		}
+	})
+}
+
+// observeError counts an error that occurred when responding to an Invocation.
+func (h *Harmonia) observeError(i *Invocation, err error) {
+	if err != nil {
+		h.metrics().HandlerError(i.handlerName(), "response")
+	}
+}
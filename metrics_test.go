@@ -0,0 +1,96 @@
+package harmonia
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsDispatch(t *testing.T) {
+	metrics := NewPrometheusMetrics().WithBuckets(60)
+	harm := &Harmonia{
+		REST:              &recordingREST{},
+		Metrics:           metrics,
+		Commands:          make(map[string]CommandHandler),
+		ComponentHandlers: make(map[string]CommandFunc),
+	}
+	harm.AddCommand(NewGroupSlashCommand("admin").WithDescription("Moderation").WithSubCommands(NewSlashCommand("ban").WithDescription("Bans a user").WithCommand(func(h *Harmonia, i *Invocation) {
+		msg, _ := h.Respond(i, "are you sure?")
+		h.AddComponentHandlerToInteractionMessage(msg, "confirm", func(h *Harmonia, i *Invocation) {
+			panic("oops")
+		})
+	})))
+
+	harm.Dispatch(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type: discordgo.InteractionApplicationCommand,
+		Data: discordgo.ApplicationCommandInteractionData{Name: "admin", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "ban", Type: discordgo.ApplicationCommandOptionSubCommand},
+		}},
+	}})
+	harm.Wait()
+	harm.Dispatch(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:    discordgo.InteractionMessageComponent,
+		Message: &discordgo.Message{ID: "message"},
+		Data:    discordgo.MessageComponentInteractionData{CustomID: "confirm"},
+	}})
+	harm.Wait()
+
+	var buf bytes.Buffer
+	metrics.WriteTo(&buf)
+	output := buf.String()
+
+	assert.Contains(t, output, "# TYPE harmonia_command_invocations_total counter\n")
+	assert.Contains(t, output, "harmonia_command_invocations_total{command=\"admin ban\"} 1\n")
+	assert.Contains(t, output, "harmonia_component_clicks_total{custom_id=\"confirm\"} 1\n")
+	assert.Contains(t, output, "harmonia_response_latency_seconds_count{handler=\"admin ban\"} 1\n")
+	assert.Contains(t, output, "harmonia_handler_duration_seconds_bucket{handler=\"admin ban\",le=\"60\"} 1\n")
+	assert.Contains(t, output, "harmonia_handler_duration_seconds_count{handler=\"confirm\"} 1\n")
+	assert.Contains(t, output, "harmonia_handler_errors_total{handler=\"confirm\",kind=\"panic\"} 1\n")
+	assert.Contains(t, output, "harmonia_message_component_handlers 1\n")
+
+	harm.RemoveComponentHandler("message-confirm")
+	buf.Reset()
+	metrics.WriteTo(&buf)
+	assert.Contains(t, buf.String(), "harmonia_message_component_handlers 0\n")
+}
+
+func TestPrometheusHistogram(t *testing.T) {
+	metrics := NewPrometheusMetrics().WithBuckets(0.1, 1)
+	metrics.HandlerFinished("say \"hi\"", 50*time.Millisecond)
+	metrics.HandlerFinished("say \"hi\"", 500*time.Millisecond)
+	metrics.HandlerFinished("say \"hi\"", 2*time.Second)
+
+	var buf bytes.Buffer
+	metrics.WriteTo(&buf)
+	assert.Contains(t, buf.String(), `harmonia_handler_duration_seconds_bucket{handler="say \"hi\"",le="0.1"} 1
+harmonia_handler_duration_seconds_bucket{handler="say \"hi\"",le="1"} 2
+harmonia_handler_duration_seconds_bucket{handler="say \"hi\"",le="+Inf"} 3
+harmonia_handler_duration_seconds_sum{handler="say \"hi\""} 2.55
+harmonia_handler_duration_seconds_count{handler="say \"hi\""} 3
+`)
+}
+
+func TestMetricsUnknownComponent(t *testing.T) {
+	metrics := NewPrometheusMetrics()
+	harm := &Harmonia{REST: &recordingREST{}, Metrics: metrics, ComponentHandlers: make(map[string]CommandFunc)}
+
+	for _, customID := range []string{"stale-1", "stale-2"} {
+		harm.Dispatch(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type:    discordgo.InteractionMessageComponent,
+			Message: &discordgo.Message{ID: "message"},
+			Data:    discordgo.MessageComponentInteractionData{CustomID: customID},
+		}})
+	}
+	harm.Wait()
+
+	var buf bytes.Buffer
+	metrics.WriteTo(&buf)
+	output := buf.String()
+
+	assert.Contains(t, output, "harmonia_handler_duration_seconds_count{handler=\"unknown_component\"} 2\n")
+	assert.Contains(t, output, "harmonia_response_latency_seconds_count{handler=\"unknown_component\"} 2\n")
+	assert.NotContains(t, output, "stale")
+}
@@ -0,0 +1,231 @@
+package harmonia
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// A Module groups the commands, component handlers and event listeners of one feature of a bot, so they can be loaded and unloaded together, see LoadModule.
+type Module interface {
+	// Name returns the name the Module is loaded under, which has to be unique.
+	Name() string
+	// Commands returns the commands of the Module.
+	Commands() []CommandHandler
+	// ComponentHandlers returns the global component handlers of the Module by CustomID, see AddComponentHandler.
+	ComponentHandlers() map[string]CommandFunc
+	// Events returns the event listeners of the Module.
+	Events() []Listener
+	// Init is called once the Module is loaded, such as to restore state. When it returns an error, the Module is unloaded again.
+	// Init is called without holding the lock of the Modules, so it can call LoadModule to load a Module it depends on, Modules or UpdateCommand.
+	Init(h *Harmonia) error
+	// Shutdown is called when the Module is unloaded, after its commands and handlers have been removed.
+	// Handlers the Module added to InteractionMessages itself should be removed here. Like Init, it is called without holding the lock of the Modules.
+	Shutdown(h *Harmonia) error
+}
+
+// A Listener adds an event listener, returning the function that removes it. The functions that add listeners, such as OnMemberJoin, can be wrapped:
+//
+//	func(h *harmonia.Harmonia) func() { return h.OnMemberJoin(welcome) }
+type Listener func(h *Harmonia) (remove func())
+
+// loadedModule keeps what was added for a Module, to remove it again when it is unloaded.
+type loadedModule struct {
+	module     Module
+	commands   []CommandHandler
+	customIDs  []string
+	removeFunc []func()
+	// loading is set until Init has returned, the Module is not returned by Modules and can not be unloaded before then.
+	loading bool
+}
+
+// LoadModule adds the commands, component handlers and event listeners of a Module and calls its Init.
+// The commands and component handlers are added all at once, or not at all when one of them is not valid or has the name of one that already exists.
+//
+// When commands were already registered with RegisterCommands, such as by Run, the commands of the Module are registered with the Discord API as well.
+// Otherwise they are registered together with the other commands.
+func (h *Harmonia) LoadModule(m Module) error {
+	name := m.Name()
+	loaded := &loadedModule{module: m, commands: m.Commands(), loading: true}
+	// UpdateCommand can swap the commands of the Module once it was added, so they are registered from a copy.
+	commands := append([]CommandHandler(nil), loaded.commands...)
+	register, err := h.addModule(loaded)
+	if err != nil {
+		return err
+	}
+
+	// The lock of the Modules is not held from here on, so that Init can load other Modules or update commands.
+	if register {
+		for _, command := range commands {
+			if err := h.registerCommand(context.Background(), command); err != nil {
+				// Only the commands that were registered have an ID, so only those are removed from the Discord API.
+				h.removeModule(loaded)
+				return err
+			}
+		}
+	}
+
+	for _, listener := range m.Events() {
+		loaded.removeFunc = append(loaded.removeFunc, listener(h))
+	}
+
+	if err := m.Init(h); err != nil {
+		h.removeModule(loaded)
+		return fmt.Errorf("module '%v' could not be initialised: %w", name, err)
+	}
+
+	h.modulesMu.Lock()
+	loaded.loading = false
+	h.modulesMu.Unlock()
+	h.logger().Info("loaded module", "module", name, "commands", len(loaded.commands), "component_handlers", len(loaded.customIDs))
+	return nil
+}
+
+// addModule adds the commands and component handlers of a Module and reserves its name, returning whether its commands have to be registered.
+// The commands are added while holding the lock of the Modules, so that RegisterCommands either registers them itself or was run before.
+func (h *Harmonia) addModule(loaded *loadedModule) (register bool, err error) {
+	h.modulesMu.Lock()
+	defer h.modulesMu.Unlock()
+
+	m := loaded.module
+	name := m.Name()
+	if _, ok := h.modules[name]; ok {
+		return false, fmt.Errorf("module '%v' is already loaded", name)
+	}
+
+	errs := make([]error, 0)
+	for _, command := range loaded.commands {
+		errs = append(errs, validateCommand(command)...)
+	}
+	if err := newValidationError(errs); err != nil {
+		return false, err
+	}
+
+	handlers := m.ComponentHandlers()
+	for customID := range handlers {
+		if customID == "" {
+			return false, fmt.Errorf("module '%v' has a component handler with an empty CustomID", name)
+		}
+		loaded.customIDs = append(loaded.customIDs, customID)
+	}
+	sort.Strings(loaded.customIDs)
+
+	if err := h.addModuleHandlers(loaded, handlers); err != nil {
+		return false, fmt.Errorf("module '%v' can not be loaded: %w", name, err)
+	}
+
+	if h.modules == nil {
+		h.modules = make(map[string]*loadedModule)
+	}
+	h.modules[name] = loaded
+	return h.registered, nil
+}
+
+// removeModule removes a Module that could not be loaded.
+func (h *Harmonia) removeModule(loaded *loadedModule) {
+	h.modulesMu.Lock()
+	delete(h.modules, loaded.module.Name())
+	h.modulesMu.Unlock()
+
+	h.unloadModule(loaded)
+}
+
+// addModuleHandlers adds the commands and component handlers of a Module while holding the lock of the handlers, so that none are added when one of them exists already.
+func (h *Harmonia) addModuleHandlers(loaded *loadedModule, handlers map[string]CommandFunc) error {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+
+	names := make(map[string]bool, len(loaded.commands))
+	for _, command := range loaded.commands {
+		name := command.GetName()
+		if _, ok := h.Commands[name]; ok || names[name] {
+			return fmt.Errorf("command '%v' already exists", name)
+		}
+		names[name] = true
+	}
+	for _, customID := range loaded.customIDs {
+		if _, ok := h.ComponentHandlers[customID]; ok {
+			return fmt.Errorf("CustomID '%v' already exists", customID)
+		}
+	}
+
+	for _, command := range loaded.commands {
+		h.Commands[command.GetName()] = command
+	}
+	for _, customID := range loaded.customIDs {
+		h.ComponentHandlers[customID] = handlers[customID]
+	}
+	return nil
+}
+
+// UnloadModule removes the commands, component handlers and event listeners of a loaded Module, and then calls its Shutdown.
+// Registered commands of the Module are removed from the Discord API as well. Handlers that are still running are not waited for, see Wait.
+//
+// The Module is unloaded even when removing its commands from the Discord API or Shutdown fails, the first error that occurred is returned.
+func (h *Harmonia) UnloadModule(name string) error {
+	h.modulesMu.Lock()
+	loaded, ok := h.modules[name]
+	if !ok || loaded.loading {
+		h.modulesMu.Unlock()
+		return fmt.Errorf("module '%v' is not loaded", name)
+	}
+	delete(h.modules, name)
+	h.modulesMu.Unlock()
+
+	err := h.unloadModule(loaded)
+	if shutdownErr := loaded.module.Shutdown(h); err == nil && shutdownErr != nil {
+		err = fmt.Errorf("module '%v' could not be shut down: %w", name, shutdownErr)
+	}
+	h.logger().Info("unloaded module", "module", name)
+	return err
+}
+
+// unloadModule removes everything that was added for a Module, returning the first error that occurred when removing its commands from the Discord API.
+func (h *Harmonia) unloadModule(loaded *loadedModule) error {
+	for _, remove := range loaded.removeFunc {
+		remove()
+	}
+
+	h.handlersMu.Lock()
+	for _, command := range loaded.commands {
+		if h.Commands[command.GetName()] == command {
+			delete(h.Commands, command.GetName())
+		}
+	}
+	for _, customID := range loaded.customIDs {
+		delete(h.ComponentHandlers, customID)
+	}
+	h.handlersMu.Unlock()
+
+	var first error
+	for _, command := range loaded.commands {
+		registration := h.registration(command)
+		if registration.ID == "" {
+			continue
+		}
+		if err := h.RESTClient().ApplicationCommandDelete(h.appID(), registration.GuildID, registration.ID); err != nil {
+			h.logger().Error("could not remove command", "command", registration.Name, "command_id", registration.ID, "error", err)
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		// The command is registered again when the Module is loaded again.
+		h.setRegistration(command, nil)
+	}
+	return first
+}
+
+// Modules returns the Modules that are loaded, sorted by name.
+func (h *Harmonia) Modules() []Module {
+	h.modulesMu.Lock()
+	defer h.modulesMu.Unlock()
+
+	modules := make([]Module, 0, len(h.modules))
+	for _, name := range sortedKeys(h.modules) {
+		if !h.modules[name].loading {
+			modules = append(modules, h.modules[name].module)
+		}
+	}
+	return modules
+}
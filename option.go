@@ -9,22 +9,35 @@ import (
 // An Option is a wrapper around an ApplicationCommandOption with added functionality.
 type Option struct {
 	*discordgo.ApplicationCommandOption
+
+	autocomplete AutocompleteFunc
+	// rejected are the choices that were not added because their value does not match the type of the Option, which are reported by validate.
+	rejected []*discordgo.ApplicationCommandOptionChoice
 }
 
+// An AutocompleteFunc returns the choices to suggest for an option while the user is typing value.
+// The other options the user already filled in are available on the Invocation. At most 25 choices are shown.
+type AutocompleteFunc func(h *Harmonia, i *Invocation, value string) []*discordgo.ApplicationCommandOptionChoice
+
 // NewOption returns an option with given name and type.
+// The name is validated together with the command it is added to, see MustNewOption to validate it right away.
 func NewOption(name string, t discordgo.ApplicationCommandOptionType) *Option {
-	if name == "" {
-		log.Panic("empty option name")
-	}
-
 	return &Option{
-		&discordgo.ApplicationCommandOption{
+		ApplicationCommandOption: &discordgo.ApplicationCommandOption{
 			Type: t,
 			Name: name,
 		},
 	}
 }
 
+// MustNewOption does the same as NewOption, but panics if the name is not valid.
+func MustNewOption(name string, t discordgo.ApplicationCommandOptionType) *Option {
+	if err := validateChatName("option", name); err != nil {
+		log.Panic(err)
+	}
+	return NewOption(name, t)
+}
+
 // WithDescription changes the description of the Option and returns itself, so that it can be chained.
 func (o *Option) WithDescription(description string) *Option {
 	o.Description = description
@@ -38,11 +51,58 @@ func (o *Option) IsRequired() *Option {
 }
 
 // AddChoice adds a choice to an option, value should be the same as the choice's type and returns itself, so that it can be chained.
+// The value has to be a string for string options, an integer type for integer options, and an integer or float type for number options.
+// Choices with other values are not added, they are reported when the command of the Option is validated.
 func (o *Option) AddChoice(name string, value interface{}) *Option {
 	c := &discordgo.ApplicationCommandOptionChoice{
 		Name:  name,
 		Value: value,
 	}
+
+	if !choiceMatchesType(o.Type, value) {
+		o.rejected = append(o.rejected, c)
+		return o
+	}
+
 	o.Choices = append(o.Choices, c)
 	return o
 }
+
+// WithMinValue changes the minimum value of an integer or number Option and returns itself, so that it can be chained.
+func (o *Option) WithMinValue(min float64) *Option {
+	o.MinValue = &min
+	return o
+}
+
+// WithMaxValue changes the maximum value of an integer or number Option and returns itself, so that it can be chained.
+// Discord does not distinguish a maximum of 0 from no maximum.
+func (o *Option) WithMaxValue(max float64) *Option {
+	o.MaxValue = max
+	return o
+}
+
+// WithMinLength changes the minimum length of a string Option and returns itself, so that it can be chained.
+func (o *Option) WithMinLength(min int) *Option {
+	o.MinLength = &min
+	return o
+}
+
+// WithMaxLength changes the maximum length of a string Option and returns itself, so that it can be chained.
+func (o *Option) WithMaxLength(max int) *Option {
+	o.MaxLength = max
+	return o
+}
+
+// WithChannelTypes restricts a channel Option to channels of the given types and returns itself, so that it can be chained.
+func (o *Option) WithChannelTypes(types ...discordgo.ChannelType) *Option {
+	o.ChannelTypes = append(o.ChannelTypes, types...)
+	return o
+}
+
+// WithAutocomplete makes Discord ask for choices while the user is typing the Option and returns itself, so that it can be chained.
+// Only string, integer and number options without choices can be autocompleted.
+func (o *Option) WithAutocomplete(autocomplete AutocompleteFunc) *Option {
+	o.Autocomplete = true
+	o.autocomplete = autocomplete
+	return o
+}
@@ -1,6 +1,7 @@
 package harmonia
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/bwmarrin/discordgo"
@@ -13,14 +14,14 @@ func TestAddOption(t *testing.T) {
 
 	assert.Equal(t, 0, len(s.options))
 
-	assert.Panics(t, func() { NewOption("", discordgo.ApplicationCommandOptionChannel) })
+	assert.Panics(t, func() { MustNewOption("", discordgo.ApplicationCommandOptionChannel) })
 	opt := NewOption("testOption", discordgo.ApplicationCommandOptionBoolean).
 		WithDescription("Testing Option").
 		IsRequired()
 	s.WithOptions(opt)
 
 	assert.NotNil(t, opt)
-	assert.Equal(t, &Option{&discordgo.ApplicationCommandOption{
+	assert.Equal(t, &Option{ApplicationCommandOption: &discordgo.ApplicationCommandOption{
 		Type:        discordgo.ApplicationCommandOptionBoolean,
 		Name:        "testOption",
 		Description: "Testing Option",
@@ -43,3 +44,57 @@ func TestAddChoice(t *testing.T) {
 		Value: "5",
 	}, o.Choices[0])
 }
+
+func TestAddChoiceTypes(t *testing.T) {
+	tests := []struct {
+		t        discordgo.ApplicationCommandOptionType
+		accepted []interface{}
+		rejected []interface{}
+	}{
+		{discordgo.ApplicationCommandOptionString, []interface{}{"a"}, []interface{}{1, 1.5, true}},
+		{discordgo.ApplicationCommandOptionInteger, []interface{}{1, int64(2), uint8(3)}, []interface{}{"1", 1.0, 1.5}},
+		{discordgo.ApplicationCommandOptionNumber, []interface{}{1, 1.5, float32(2)}, []interface{}{"1.5", false}},
+		{discordgo.ApplicationCommandOptionBoolean, nil, []interface{}{true}},
+		{discordgo.ApplicationCommandOptionUser, nil, []interface{}{"123"}},
+		{discordgo.ApplicationCommandOptionChannel, nil, []interface{}{"123"}},
+		{discordgo.ApplicationCommandOptionRole, nil, []interface{}{"123"}},
+		{discordgo.ApplicationCommandOptionMentionable, nil, []interface{}{"123"}},
+		{discordgo.ApplicationCommandOptionAttachment, nil, []interface{}{"123"}},
+	}
+
+	for _, test := range tests {
+		o := NewOption("option", test.t)
+		for _, value := range test.accepted {
+			o.AddChoice("accepted", value)
+		}
+		for _, value := range test.rejected {
+			o.AddChoice("rejected", value)
+		}
+
+		assert.Len(t, o.Choices, len(test.accepted), test.t.String())
+		assert.Len(t, o.rejected, len(test.rejected), test.t.String())
+		assert.NotEmpty(t, o.validate("command"), test.t.String())
+	}
+}
+
+func TestOptionConstraints(t *testing.T) {
+	assert.Empty(t, NewOption("amount", discordgo.ApplicationCommandOptionInteger).WithMinValue(1).WithMaxValue(10).validate("command"))
+	assert.Empty(t, NewOption("amount", discordgo.ApplicationCommandOptionNumber).WithMinValue(-0.5).validate("command"))
+	assert.Empty(t, NewOption("reason", discordgo.ApplicationCommandOptionString).WithMinLength(0).WithMaxLength(200).validate("command"))
+	assert.Empty(t, NewOption("channel", discordgo.ApplicationCommandOptionChannel).WithChannelTypes(discordgo.ChannelTypeGuildText).validate("command"))
+
+	errs := NewOption("amount", discordgo.ApplicationCommandOptionInteger).WithMinValue(10).WithMaxValue(1).WithMaxLength(5).validate("command")
+	assert.Equal(t, []error{
+		errors.New("minimum value of option 'amount' of 'command' is larger than its maximum value"),
+		errors.New("option 'amount' of 'command' can not have a minimum or maximum length"),
+	}, errs)
+
+	errs = NewOption("reason", discordgo.ApplicationCommandOptionString).WithMinLength(10).WithMaxLength(7000).WithMinValue(1).validate("command")
+	assert.Equal(t, []error{
+		errors.New("option 'reason' of 'command' can not have a minimum or maximum value"),
+		errors.New("length of option 'reason' of 'command' has to be between 0 and 6000"),
+	}, errs)
+
+	errs = NewOption("user", discordgo.ApplicationCommandOptionUser).WithChannelTypes(discordgo.ChannelTypeGuildText).validate("command")
+	assert.Equal(t, []error{errors.New("option 'user' of 'command' can not have channel types")}, errs)
+}
@@ -0,0 +1,87 @@
+package harmonia
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// permissionNames contains the human readable names of the permission flags, in the order Discord displays them.
+var permissionNames = []struct {
+	flag int64
+	name string
+}{
+	{discordgo.PermissionAdministrator, "Administrator"},
+	{discordgo.PermissionViewAuditLogs, "View Audit Log"},
+	{discordgo.PermissionViewGuildInsights, "View Server Insights"},
+	{discordgo.PermissionManageServer, "Manage Server"},
+	{discordgo.PermissionManageRoles, "Manage Roles"},
+	{discordgo.PermissionManageChannels, "Manage Channels"},
+	{discordgo.PermissionKickMembers, "Kick Members"},
+	{discordgo.PermissionBanMembers, "Ban Members"},
+	{discordgo.PermissionModerateMembers, "Timeout Members"},
+	{discordgo.PermissionCreateInstantInvite, "Create Invite"},
+	{discordgo.PermissionChangeNickname, "Change Nickname"},
+	{discordgo.PermissionManageNicknames, "Manage Nicknames"},
+	{discordgo.PermissionManageEmojis, "Manage Emojis and Stickers"},
+	{discordgo.PermissionManageWebhooks, "Manage Webhooks"},
+	{discordgo.PermissionManageEvents, "Manage Events"},
+	{discordgo.PermissionViewChannel, "View Channels"},
+	{discordgo.PermissionSendMessages, "Send Messages"},
+	{discordgo.PermissionSendMessagesInThreads, "Send Messages in Threads"},
+	{discordgo.PermissionCreatePublicThreads, "Create Public Threads"},
+	{discordgo.PermissionCreatePrivateThreads, "Create Private Threads"},
+	{discordgo.PermissionSendTTSMessages, "Send Text-to-Speech Messages"},
+	{discordgo.PermissionManageMessages, "Manage Messages"},
+	{discordgo.PermissionManageThreads, "Manage Threads"},
+	{discordgo.PermissionEmbedLinks, "Embed Links"},
+	{discordgo.PermissionAttachFiles, "Attach Files"},
+	{discordgo.PermissionReadMessageHistory, "Read Message History"},
+	{discordgo.PermissionMentionEveryone, "Mention @everyone, @here, and All Roles"},
+	{discordgo.PermissionUseExternalEmojis, "Use External Emoji"},
+	{discordgo.PermissionUseExternalStickers, "Use External Stickers"},
+	{discordgo.PermissionAddReactions, "Add Reactions"},
+	{discordgo.PermissionUseSlashCommands, "Use Application Commands"},
+	{discordgo.PermissionUseActivities, "Use Activities"},
+	{discordgo.PermissionVoiceConnect, "Connect"},
+	{discordgo.PermissionVoiceSpeak, "Speak"},
+	{discordgo.PermissionVoiceStreamVideo, "Video"},
+	{discordgo.PermissionVoiceUseVAD, "Use Voice Activity"},
+	{discordgo.PermissionVoicePrioritySpeaker, "Priority Speaker"},
+	{discordgo.PermissionVoiceMuteMembers, "Mute Members"},
+	{discordgo.PermissionVoiceDeafenMembers, "Deafen Members"},
+	{discordgo.PermissionVoiceMoveMembers, "Move Members"},
+	{discordgo.PermissionVoiceRequestToSpeak, "Request to Speak"},
+}
+
+// allPermissions contains every permission flag known to Harmonia.
+var allPermissions = func() (perms int64) {
+	for _, p := range permissionNames {
+		perms |= p.flag
+	}
+	return perms
+}()
+
+// PermissionNames returns the human readable names of all permission flags set in perms.
+func PermissionNames(perms int64) []string {
+	names := make([]string, 0)
+	for _, p := range permissionNames {
+		if perms&p.flag == p.flag {
+			names = append(names, p.name)
+		}
+	}
+	return names
+}
+
+// MissingPermissions returns the permission flags in required that are not present in perms.
+// The Administrator permission implicitly grants every other permission.
+func MissingPermissions(perms, required int64) int64 {
+	if perms&discordgo.PermissionAdministrator != 0 {
+		return 0
+	}
+	return required &^ perms
+}
+
+func formatPermissions(perms int64) string {
+	return strings.Join(PermissionNames(perms), ", ")
+}
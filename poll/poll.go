@@ -0,0 +1,555 @@
+// Package poll provides a /poll command for Harmonia, with the subcommands create, close and results.
+//
+// A poll is a message with a button for every option. Clicking a button votes for that option, or takes the vote back when clicked again,
+// and the tallies on the message are updated right away. Polls can close by themselves after a number of minutes.
+// Votes are kept in a Store, so that the polls that are still open keep being handled after a restart.
+// Polls is a harmonia.Module, load it with LoadModule, or add Command yourself and call Restore when the bot starts.
+//
+// Besides being useful on its own, the package shows how a larger module can be built from a GroupSlashCommand, component handlers and a store.
+package poll
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Moonlington/harmonia"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxOptions is the number of buttons Discord allows on a message.
+	maxOptions = 25
+	// maxMinutes is the longest a poll created with /poll create can stay open, four weeks.
+	maxMinutes = 4 * 7 * 24 * 60
+	// maxLength is the number of characters Discord allows in the content of a message.
+	maxLength = 2000
+	// tallyLength is how many characters the tally of an option, or the number of voters, can grow by as votes come in,
+	// from "0 votes (0%)" to "1000000 votes (100%)".
+	tallyLength = 10
+)
+
+// A Poll describes a question that is voted on through the buttons on a message.
+type Poll struct {
+	MessageID string `json:"message_id"`
+	ChannelID string `json:"channel_id"`
+	GuildID   string `json:"guild_id"`
+	// CreatorID is the ID of the user that created the Poll, who is allowed to close it.
+	CreatorID string   `json:"creator_id"`
+	Question  string   `json:"question"`
+	Options   []string `json:"options"`
+	// Multiple allows users to vote for more than one option, otherwise voting for another option moves their vote.
+	Multiple bool `json:"multiple"`
+	// Ends is when the Poll closes by itself, it stays open until it is closed when Ends is zero.
+	Ends   time.Time `json:"ends,omitempty"`
+	Closed bool      `json:"closed"`
+}
+
+// validate checks the question and options of the Poll against the limits of Discord.
+func (poll *Poll) validate() error {
+	if strings.TrimSpace(poll.Question) == "" {
+		return errors.New("a poll needs a question")
+	}
+	if len(poll.Options) < 2 {
+		return errors.New("a poll needs at least two options")
+	}
+	if len(poll.Options) > maxOptions {
+		return fmt.Errorf("a poll has %v options, at most %v are allowed", len(poll.Options), maxOptions)
+	}
+	for _, option := range poll.Options {
+		if option == "" || utf8.RuneCountInString(option) > 80 {
+			return fmt.Errorf("option '%v' has to be between 1 and 80 characters long", option)
+		}
+	}
+
+	// The message has to fit the tallies of the options and the footer once the votes come in, and once the Poll is closed.
+	length := utf8.RuneCountInString(poll.Render(nil)) + (len(poll.Options)+1)*tallyLength + len(" · closed")
+	if length > maxLength {
+		return fmt.Errorf("the question and options of a poll are too long to fit in a message, shorten them by %v characters", length-maxLength)
+	}
+	return nil
+}
+
+// tally counts the votes for every option of the Poll.
+func (poll *Poll) tally(votes map[string][]int) []int {
+	counts := make([]int, len(poll.Options))
+	for _, choices := range votes {
+		for _, choice := range choices {
+			if choice >= 0 && choice < len(counts) {
+				counts[choice]++
+			}
+		}
+	}
+	return counts
+}
+
+// Render returns the content of the message of the Poll with the given votes, see Store.Votes.
+func (poll *Poll) Render(votes map[string][]int) string {
+	counts := poll.tally(votes)
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 **%v**\n", poll.Question)
+	for n, option := range poll.Options {
+		percentage := 0
+		if total > 0 {
+			percentage = (counts[n]*100 + total/2) / total
+		}
+		fmt.Fprintf(&b, "%v: %v (%v%%)\n", option, plural(counts[n], "vote"), percentage)
+	}
+
+	footer := []string{plural(len(votes), "voter")}
+	if poll.Multiple {
+		footer = append(footer, "more than one option can be picked")
+	}
+	switch {
+	case poll.Closed:
+		footer = append(footer, "closed")
+	case !poll.Ends.IsZero():
+		footer = append(footer, fmt.Sprintf("closes <t:%v:R>", poll.Ends.Unix()))
+	}
+	b.WriteString(strings.Join(footer, " · "))
+	return b.String()
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%v %v", n, noun)
+	}
+	return fmt.Sprintf("%v %vs", n, noun)
+}
+
+// buttons returns a button for every option of the Poll, five to a row.
+func (poll *Poll) buttons() [][]discordgo.MessageComponent {
+	rows := make([][]discordgo.MessageComponent, 0)
+	for n, option := range poll.Options {
+		if n%5 == 0 {
+			rows = append(rows, make([]discordgo.MessageComponent, 0, 5))
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], discordgo.Button{
+			Label:    option,
+			Style:    discordgo.SecondaryButton,
+			CustomID: customID(n),
+		})
+	}
+	return rows
+}
+
+func customID(choice int) string {
+	return fmt.Sprintf("poll-%v", choice)
+}
+
+// Polls creates polls and handles voting on them.
+type Polls struct {
+	h     *harmonia.Harmonia
+	store Store
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+	// handled are the IDs of the messages of the polls that are handled.
+	handled map[string]bool
+	// locks are held while a Poll is voted on or closed, so that its message is updated in the same order as its votes change.
+	locks map[string]*sync.Mutex
+}
+
+// New returns Polls that keeps its polls in the given Store. Add the /poll command with Command, and call Restore to handle the polls that are still open.
+func New(h *harmonia.Harmonia, store Store) *Polls {
+	return &Polls{h: h, store: store, timers: make(map[string]*time.Timer), handled: make(map[string]bool), locks: make(map[string]*sync.Mutex)}
+}
+
+// Command returns the /poll GroupSlashCommand, which can be added with AddCommand.
+func (p *Polls) Command() *harmonia.GroupSlashCommand {
+	return harmonia.NewGroupSlashCommand("poll").
+		WithDescription("Create and manage polls").
+		WithGuards(harmonia.GuildOnly).
+		WithSubCommands(
+			harmonia.NewSlashCommand("create").
+				WithDescription("Create a poll in this channel").
+				WithOptions(
+					harmonia.NewOption("question", discordgo.ApplicationCommandOptionString).WithDescription("The question to vote on").IsRequired().WithMaxLength(300),
+					harmonia.NewOption("options", discordgo.ApplicationCommandOptionString).WithDescription("The options to vote for, separated by |").IsRequired(),
+					harmonia.NewOption("multiple", discordgo.ApplicationCommandOptionBoolean).WithDescription("Whether more than one option can be voted for"),
+					harmonia.NewOption("minutes", discordgo.ApplicationCommandOptionInteger).WithDescription("How many minutes until the poll closes").WithMinValue(1).WithMaxValue(maxMinutes),
+				).
+				WithBotPermissions(discordgo.PermissionViewChannel|discordgo.PermissionSendMessages).
+				WithCommand(p.create),
+			harmonia.NewSlashCommand("close").
+				WithDescription("Close a poll, so that it can no longer be voted on").
+				WithOptions(p.pollOption(true)).
+				WithCommand(p.close),
+			harmonia.NewSlashCommand("results").
+				WithDescription("Show the results of a poll").
+				WithOptions(p.pollOption(false)).
+				WithCommand(p.results),
+		)
+}
+
+// Name returns the name of the Module, "poll".
+func (p *Polls) Name() string {
+	return "poll"
+}
+
+// Commands returns the /poll command, see Command.
+func (p *Polls) Commands() []harmonia.CommandHandler {
+	return []harmonia.CommandHandler{p.Command()}
+}
+
+// ComponentHandlers returns no handlers, the buttons of every poll get their own handlers.
+func (p *Polls) ComponentHandlers() map[string]harmonia.CommandFunc {
+	return nil
+}
+
+// Events returns no listeners, polls are only voted on with buttons.
+func (p *Polls) Events() []harmonia.Listener {
+	return nil
+}
+
+// Init restores the polls that are still open, see Restore.
+func (p *Polls) Init(h *harmonia.Harmonia) error {
+	return p.Restore()
+}
+
+// Shutdown stops handling the polls that are still open, without closing them, so that they can be restored again.
+func (p *Polls) Shutdown(h *harmonia.Harmonia) error {
+	polls, err := p.store.Polls()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	for id, timer := range p.timers {
+		timer.Stop()
+		delete(p.timers, id)
+	}
+	for id := range p.handled {
+		delete(p.handled, id)
+	}
+	p.mu.Unlock()
+
+	for _, poll := range polls {
+		if poll.Closed {
+			continue
+		}
+		message := interactionMessage(poll)
+		for n := range poll.Options {
+			p.h.RemoveComponentHandlerFromInteractionMessage(message, customID(n))
+		}
+	}
+	return nil
+}
+
+// pollOption returns the option to pick a poll of the guild with, suggesting only the polls that are open when open is set.
+func (p *Polls) pollOption(open bool) *harmonia.Option {
+	return harmonia.NewOption("poll", discordgo.ApplicationCommandOptionString).
+		WithDescription("The poll, by the ID of its message").
+		IsRequired().
+		WithAutocomplete(func(h *harmonia.Harmonia, i *harmonia.Invocation, value string) []*discordgo.ApplicationCommandOptionChoice {
+			polls, err := p.store.Polls()
+			if err != nil {
+				return nil
+			}
+
+			choices := make([]*discordgo.ApplicationCommandOptionChoice, 0)
+			for n := len(polls) - 1; n >= 0 && len(choices) < 25; n-- {
+				poll := polls[n]
+				if poll.GuildID != i.GuildID || (open && poll.Closed) || !strings.Contains(strings.ToLower(poll.Question), strings.ToLower(value)) {
+					continue
+				}
+
+				name := poll.Question
+				if len(name) > 100 {
+					name = name[:97] + "..."
+				}
+				choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: poll.MessageID})
+			}
+			return choices
+		})
+}
+
+func (p *Polls) create(h *harmonia.Harmonia, i *harmonia.Invocation) {
+	author, err := i.GetAuthor()
+	if err != nil {
+		h.EphemeralRespond(i, "Could not create the poll.")
+		return
+	}
+
+	poll := &Poll{
+		ChannelID: i.ChannelID,
+		GuildID:   i.GuildID,
+		CreatorID: author.ID,
+		Question:  i.GetOption("question").StringValue(),
+	}
+	for _, option := range strings.Split(i.GetOption("options").StringValue(), "|") {
+		if option = strings.TrimSpace(option); option != "" {
+			poll.Options = append(poll.Options, option)
+		}
+	}
+	if option := i.GetOption("multiple"); option != nil {
+		poll.Multiple = option.BoolValue()
+	}
+	if option := i.GetOption("minutes"); option != nil {
+		poll.Ends = time.Now().Add(time.Duration(option.IntValue()) * time.Minute)
+	}
+
+	if err := p.Create(poll); err != nil {
+		h.EphemeralRespond(i, err.Error())
+		return
+	}
+	h.EphemeralRespond(i, "Created the poll.")
+}
+
+// Create sends the message of a Poll to its channel and starts handling votes on it. The MessageID of the Poll is set to that of the message.
+func (p *Polls) Create(poll *Poll) error {
+	if err := poll.validate(); err != nil {
+		return err
+	}
+
+	message, err := p.h.RESTClient().ChannelMessageSendComplex(poll.ChannelID, &discordgo.MessageSend{
+		Content:         poll.Render(nil),
+		Components:      harmonia.ParseComponentMatrix(poll.buttons()),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		return err
+	}
+
+	poll.MessageID = message.ID
+	if err := p.store.SavePoll(poll); err != nil {
+		return err
+	}
+	return p.open(poll)
+}
+
+// Restore handles votes on every Poll in the Store that is still open. Polls that should have closed while the bot was not running are closed.
+// Polls that are already handled are skipped, so Restore can be called again, such as when the Module is loaded again.
+func (p *Polls) Restore() error {
+	polls, err := p.store.Polls()
+	if err != nil {
+		return err
+	}
+
+	for _, poll := range polls {
+		if poll.Closed {
+			continue
+		}
+
+		if !poll.Ends.IsZero() && !poll.Ends.After(time.Now()) {
+			err = p.Close(poll.MessageID)
+		} else {
+			err = p.open(poll)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// open adds the component handlers for the buttons of the Poll, and starts the timer that closes it, unless the Poll is already handled.
+func (p *Polls) open(poll *Poll) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.handled[poll.MessageID] {
+		return nil
+	}
+
+	message := interactionMessage(poll)
+	for n := range poll.Options {
+		choice := n
+		err := p.h.AddComponentHandlerToInteractionMessage(message, customID(choice), func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+			p.vote(i, poll.MessageID, choice)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if !poll.Ends.IsZero() {
+		id := poll.MessageID
+		p.timers[id] = time.AfterFunc(time.Until(poll.Ends), func() {
+			if err := p.Close(id); err != nil && p.h.Logger != nil {
+				p.h.Logger.Error("could not close poll", "message_id", id, "error", err)
+			}
+		})
+	}
+
+	p.handled[poll.MessageID] = true
+	return nil
+}
+
+func interactionMessage(poll *Poll) *harmonia.InteractionMessage {
+	return &harmonia.InteractionMessage{Message: &discordgo.Message{ID: poll.MessageID, ChannelID: poll.ChannelID, GuildID: poll.GuildID}}
+}
+
+// lock locks the Poll on the message with the given ID, returning the function that unlocks it.
+func (p *Polls) lock(messageID string) (unlock func()) {
+	p.mu.Lock()
+	lock, ok := p.locks[messageID]
+	if !ok {
+		lock = &sync.Mutex{}
+		p.locks[messageID] = lock
+	}
+	p.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// vote changes the vote of the invoker for the given choice, and updates the tallies on the message of the Poll.
+// The Poll stays locked until the message is updated, so a slower update can not overwrite the tallies of a later vote.
+func (p *Polls) vote(i *harmonia.Invocation, messageID string, choice int) {
+	author, err := i.GetAuthor()
+	if err != nil {
+		p.h.EphemeralRespond(i, "Could not count your vote.")
+		return
+	}
+
+	unlock := p.lock(messageID)
+	defer unlock()
+
+	poll, err := p.changeVote(messageID, author.ID, choice)
+	if err != nil {
+		p.h.EphemeralRespond(i, err.Error())
+		return
+	}
+	votes, err := p.store.Votes(messageID)
+	if err != nil {
+		p.h.EphemeralRespond(i, err.Error())
+		return
+	}
+
+	if _, err := p.h.RespondComplex(i, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate}); err != nil {
+		return
+	}
+	p.h.EditResponseWithComponents(i, poll.Render(votes), poll.buttons())
+}
+
+// changeVote toggles the choice in the vote of the user, and returns the Poll. The Poll has to be locked.
+func (p *Polls) changeVote(messageID, userID string, choice int) (*Poll, error) {
+	poll, err := p.store.Poll(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if poll.Closed {
+		return nil, errors.New("this poll is closed")
+	}
+
+	votes, err := p.store.Votes(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	choices := make([]int, 0, len(votes[userID])+1)
+	voted := false
+	for _, other := range votes[userID] {
+		if other == choice {
+			voted = true
+		} else if poll.Multiple {
+			choices = append(choices, other)
+		}
+	}
+	if !voted {
+		choices = append(choices, choice)
+	}
+
+	if err := p.store.SetVotes(messageID, userID, choices); err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
+
+func (p *Polls) close(h *harmonia.Harmonia, i *harmonia.Invocation) {
+	poll, err := p.find(i)
+	if err != nil {
+		h.EphemeralRespond(i, err.Error())
+		return
+	}
+
+	if poll.CreatorID != i.Member.User.ID && i.Member.Permissions&discordgo.PermissionManageMessages == 0 {
+		h.EphemeralRespond(i, "only the creator of the poll, or members that can manage messages, can close it")
+		return
+	}
+
+	if err := p.Close(poll.MessageID); err != nil {
+		h.EphemeralRespond(i, err.Error())
+		return
+	}
+	h.EphemeralRespond(i, "Closed the poll.")
+}
+
+// Close closes the Poll on the message with the given ID, removing its buttons and showing the final tallies.
+func (p *Polls) Close(messageID string) error {
+	unlock := p.lock(messageID)
+	defer unlock()
+
+	poll, err := p.store.Poll(messageID)
+	if err == nil && poll.Closed {
+		err = fmt.Errorf("poll '%v' is already closed", messageID)
+	}
+	if err != nil {
+		return err
+	}
+
+	poll.Closed = true
+	if err := p.store.SavePoll(poll); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	if timer, ok := p.timers[messageID]; ok {
+		timer.Stop()
+		delete(p.timers, messageID)
+	}
+	delete(p.handled, messageID)
+	delete(p.locks, messageID)
+	p.mu.Unlock()
+
+	message := interactionMessage(poll)
+	for n := range poll.Options {
+		p.h.RemoveComponentHandlerFromInteractionMessage(message, customID(n))
+	}
+
+	votes, err := p.store.Votes(messageID)
+	if err != nil {
+		return err
+	}
+	content := poll.Render(votes)
+	components := []discordgo.MessageComponent{}
+	_, err = p.h.RESTClient().ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         poll.MessageID,
+		Channel:    poll.ChannelID,
+		Content:    &content,
+		Components: &components,
+	})
+	return err
+}
+
+func (p *Polls) results(h *harmonia.Harmonia, i *harmonia.Invocation) {
+	poll, err := p.find(i)
+	if err != nil {
+		h.EphemeralRespond(i, err.Error())
+		return
+	}
+
+	votes, err := p.store.Votes(poll.MessageID)
+	if err != nil {
+		h.EphemeralRespond(i, err.Error())
+		return
+	}
+	h.EphemeralRespond(i, poll.Render(votes))
+}
+
+// find returns the Poll picked with the poll option, which has to be in the guild of the Invocation.
+func (p *Polls) find(i *harmonia.Invocation) (*Poll, error) {
+	poll, err := p.store.Poll(i.GetOption("poll").StringValue())
+	if err != nil || poll.GuildID != i.GuildID {
+		return nil, ErrNotFound
+	}
+	return poll, nil
+}
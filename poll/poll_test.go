@@ -0,0 +1,141 @@
+package poll_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Moonlington/harmonia/harmoniatest"
+	"github.com/Moonlington/harmonia/poll"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	guild = &discordgo.Guild{ID: "300", OwnerID: "1", Roles: []*discordgo.Role{{ID: "300"}}}
+	alice = &discordgo.User{ID: "2", Username: "alice"}
+	bob   = &discordgo.User{ID: "3", Username: "bob"}
+)
+
+func newHarness(t *testing.T) *harmoniatest.Harness {
+	hs := harmoniatest.New(t)
+	polls := poll.New(hs.Harmonia, poll.NewMemoryStore())
+	assert.NoError(t, hs.AddCommand(polls.Command()))
+	return hs
+}
+
+// create creates a poll with /poll create as alice, returning the ID of its message.
+func create(t *testing.T, hs *harmoniatest.Harness, options ...*discordgo.ApplicationCommandInteractionDataOption) string {
+	r := hs.Simulate("poll create", append([]*discordgo.ApplicationCommandInteractionDataOption{
+		harmoniatest.StringOption("question", "Favourite colour?"),
+		harmoniatest.StringOption("options", "Red | Blue|Green"),
+	}, options...), harmoniatest.Member(guild, alice))
+	assert.Equal(t, "Created the poll.", r.Response().Message.Content)
+
+	return hs.Backend.Calls()[0].Message.ID
+}
+
+func TestVote(t *testing.T) {
+	hs := newHarness(t)
+	id := create(t, hs)
+	assert.Equal(t, "📊 **Favourite colour?**\nRed: 0 votes (0%)\nBlue: 0 votes (0%)\nGreen: 0 votes (0%)\n0 voters", hs.Backend.Message(id).Content)
+	assert.Len(t, hs.Backend.Message(id).Components, 1)
+
+	hs.SimulateComponent(id, "poll-0", nil, harmoniatest.Member(guild, alice))
+	r := hs.SimulateComponent(id, "poll-1", nil, harmoniatest.Member(guild, bob))
+	assert.Equal(t, discordgo.InteractionResponseDeferredMessageUpdate, r.Response().ResponseType)
+	assert.Equal(t, "📊 **Favourite colour?**\nRed: 1 vote (50%)\nBlue: 1 vote (50%)\nGreen: 0 votes (0%)\n2 voters", hs.Backend.Message(id).Content)
+
+	// Voting for another option moves the vote, and voting for the same option takes it back.
+	hs.SimulateComponent(id, "poll-1", nil, harmoniatest.Member(guild, alice))
+	hs.SimulateComponent(id, "poll-1", nil, harmoniatest.Member(guild, bob))
+	assert.Equal(t, "📊 **Favourite colour?**\nRed: 0 votes (0%)\nBlue: 1 vote (100%)\nGreen: 0 votes (0%)\n1 voter", hs.Backend.Message(id).Content)
+
+	typed := harmoniatest.StringOption("poll", "COLOUR")
+	typed.Focused = true
+	r = hs.SimulateAutocomplete("poll close", []*discordgo.ApplicationCommandInteractionDataOption{typed}, harmoniatest.Member(guild, bob))
+	assert.Equal(t, []*discordgo.ApplicationCommandOptionChoice{{Name: "Favourite colour?", Value: id}}, r.Response().Choices)
+}
+
+func TestMultipleAndClose(t *testing.T) {
+	hs := newHarness(t)
+	id := create(t, hs, harmoniatest.BooleanOption("multiple", true))
+
+	hs.SimulateComponent(id, "poll-0", nil, harmoniatest.Member(guild, alice))
+	hs.SimulateComponent(id, "poll-2", nil, harmoniatest.Member(guild, alice))
+
+	r := hs.Simulate("poll close", []*discordgo.ApplicationCommandInteractionDataOption{harmoniatest.StringOption("poll", id)}, harmoniatest.Member(guild, bob))
+	assert.Equal(t, "only the creator of the poll, or members that can manage messages, can close it", r.Response().Message.Content)
+
+	r = hs.Simulate("poll close", []*discordgo.ApplicationCommandInteractionDataOption{harmoniatest.StringOption("poll", id)}, harmoniatest.Member(guild, alice))
+	assert.Equal(t, "Closed the poll.", r.Response().Message.Content)
+	assert.Equal(t, "📊 **Favourite colour?**\nRed: 1 vote (50%)\nBlue: 0 votes (0%)\nGreen: 1 vote (50%)\n1 voter · more than one option can be picked · closed", hs.Backend.Message(id).Content)
+	assert.Empty(t, hs.Backend.Message(id).Components)
+
+	r = hs.SimulateComponent(id, "poll-1", nil, harmoniatest.Member(guild, bob))
+	assert.Equal(t, "This button has expired.", r.Response().Message.Content)
+
+	r = hs.Simulate("poll results", []*discordgo.ApplicationCommandInteractionDataOption{harmoniatest.StringOption("poll", id)}, harmoniatest.Member(guild, bob))
+	assert.Equal(t, hs.Backend.Message(id).Content, r.Response().Message.Content)
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, r.Response().Message.Flags)
+}
+
+func TestRestore(t *testing.T) {
+	hs := harmoniatest.New(t)
+	store := poll.NewMemoryStore()
+	p := &poll.Poll{ChannelID: harmoniatest.ChannelID, GuildID: guild.ID, CreatorID: alice.ID, Question: "Lunch?", Options: []string{"Yes", "No"}}
+	assert.NoError(t, poll.New(hs.Harmonia, store).Create(p))
+
+	restarted := harmoniatest.New(t)
+	restarted.Backend.AddGuild(guild)
+	polls := poll.New(restarted.Harmonia, store)
+	assert.NoError(t, polls.Restore())
+	assert.NoError(t, polls.Restore(), "polls that are handled already are skipped")
+
+	r := restarted.SimulateComponent(p.MessageID, "poll-0", nil, harmoniatest.Member(guild, bob))
+	assert.Equal(t, discordgo.InteractionResponseDeferredMessageUpdate, r.Response().ResponseType)
+
+	votes, err := store.Votes(p.MessageID)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]int{bob.ID: {0}}, votes)
+}
+
+func TestCreateValidation(t *testing.T) {
+	hs := newHarness(t)
+	r := hs.Simulate("poll create", []*discordgo.ApplicationCommandInteractionDataOption{
+		harmoniatest.StringOption("question", "Yes?"),
+		harmoniatest.StringOption("options", "Yes"),
+	}, harmoniatest.Member(guild, alice))
+	assert.Equal(t, "a poll needs at least two options", r.Response().Message.Content)
+
+	options := make([]string, 25)
+	for n := range options {
+		options[n] = strings.Repeat(string(rune('a'+n)), 80)
+	}
+	r = hs.Simulate("poll create", []*discordgo.ApplicationCommandInteractionDataOption{
+		harmoniatest.StringOption("question", strings.Repeat("?", 300)),
+		harmoniatest.StringOption("options", strings.Join(options, "|")),
+	}, harmoniatest.Member(guild, alice))
+	assert.Equal(t, "the question and options of a poll are too long to fit in a message, shorten them by 960 characters", r.Response().Message.Content)
+	assert.Len(t, hs.Backend.Calls(), 2, "only the responses were sent, no poll")
+
+	polls := poll.New(hs.Harmonia, poll.NewMemoryStore())
+	long := &poll.Poll{ChannelID: harmoniatest.ChannelID, GuildID: guild.ID, Question: "Which?", Options: []string{strings.Repeat("é", 80), "No"}}
+	assert.NoError(t, polls.Create(long), "options are limited in characters, not bytes")
+	long.Options[0] += "é"
+	assert.EqualError(t, polls.Create(long), "option '"+long.Options[0]+"' has to be between 1 and 80 characters long")
+}
+
+func TestModule(t *testing.T) {
+	hs := harmoniatest.New(t)
+	polls := poll.New(hs.Harmonia, poll.NewMemoryStore())
+	assert.NoError(t, hs.LoadModule(polls))
+	id := create(t, hs)
+
+	assert.NoError(t, hs.UnloadModule("poll"))
+	r := hs.SimulateComponent(id, "poll-0", nil, harmoniatest.Member(guild, alice))
+	assert.Equal(t, "This button has expired.", r.Response().Message.Content)
+
+	assert.NoError(t, hs.LoadModule(polls))
+	r = hs.SimulateComponent(id, "poll-0", nil, harmoniatest.Member(guild, alice))
+	assert.Equal(t, discordgo.InteractionResponseDeferredMessageUpdate, r.Response().ResponseType)
+}
@@ -0,0 +1,108 @@
+package poll
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrNotFound is returned by a Store when there is no poll on the message with the given ID.
+var ErrNotFound = errors.New("poll not found")
+
+// A Store keeps polls and the votes cast on them, so that polls can be restored when the bot starts.
+// Methods can be called from multiple goroutines at once.
+type Store interface {
+	// SavePoll saves a Poll, replacing the one on the same message if there is one.
+	SavePoll(p *Poll) error
+	// Poll returns the Poll on the message with the given ID, or ErrNotFound.
+	Poll(messageID string) (*Poll, error)
+	// Polls returns every Poll that was saved.
+	Polls() ([]*Poll, error)
+	// SetVotes replaces the choices a user voted for on a Poll, which are indices of its options. No choices removes the vote of the user.
+	SetVotes(messageID, userID string, choices []int) error
+	// Votes returns the choices of every user that voted on a Poll, by user ID.
+	Votes(messageID string) (map[string][]int, error)
+}
+
+// NewMemoryStore returns a MemoryStore without any polls.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{polls: make(map[string]*Poll), votes: make(map[string]map[string][]int)}
+}
+
+// A MemoryStore is a Store that keeps polls in memory, so they do not survive a restart.
+type MemoryStore struct {
+	mu    sync.Mutex
+	polls map[string]*Poll
+	votes map[string]map[string][]int
+}
+
+func (s *MemoryStore) SavePoll(p *Poll) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	poll := *p
+	s.polls[p.MessageID] = &poll
+	return nil
+}
+
+func (s *MemoryStore) Poll(messageID string) (*Poll, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.polls[messageID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	poll := *p
+	return &poll, nil
+}
+
+// Polls returns the polls ordered by the ID of their message, which is the order they were created in.
+func (s *MemoryStore) Polls() ([]*Poll, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	polls := make([]*Poll, 0, len(s.polls))
+	for _, p := range s.polls {
+		poll := *p
+		polls = append(polls, &poll)
+	}
+	// Snowflakes of the same length sort by the time they were created.
+	sort.Slice(polls, func(a, b int) bool {
+		if len(polls[a].MessageID) != len(polls[b].MessageID) {
+			return len(polls[a].MessageID) < len(polls[b].MessageID)
+		}
+		return polls[a].MessageID < polls[b].MessageID
+	})
+	return polls, nil
+}
+
+func (s *MemoryStore) SetVotes(messageID, userID string, choices []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.polls[messageID]; !ok {
+		return ErrNotFound
+	}
+	if s.votes[messageID] == nil {
+		s.votes[messageID] = make(map[string][]int)
+	}
+	if len(choices) == 0 {
+		delete(s.votes[messageID], userID)
+		return nil
+	}
+	s.votes[messageID][userID] = append([]int(nil), choices...)
+	return nil
+}
+
+func (s *MemoryStore) Votes(messageID string) (map[string][]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.polls[messageID]; !ok {
+		return nil, ErrNotFound
+	}
+	votes := make(map[string][]int, len(s.votes[messageID]))
+	for userID, choices := range s.votes[messageID] {
+		votes[userID] = append([]int(nil), choices...)
+	}
+	return votes, nil
+}
@@ -0,0 +1,206 @@
+package harmonia
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the histogram buckets PrometheusMetrics uses for latencies and durations.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// PrometheusMetrics is an implementation of Metrics that keeps counters and histograms in memory and writes them in the Prometheus text format.
+// It is an http.Handler, so it can be served from a local endpoint to be scraped:
+//
+//	metrics := harmonia.NewPrometheusMetrics()
+//	h.Metrics = metrics
+//	go http.ListenAndServe("localhost:9090", metrics)
+type PrometheusMetrics struct {
+	mu              sync.Mutex
+	buckets         []float64
+	invocations     map[string]uint64
+	clicks          map[string]uint64
+	latencies       map[string]*histogram
+	durations       map[string]*histogram
+	errors          map[[2]string]uint64
+	messageHandlers int
+}
+
+// NewPrometheusMetrics returns a PrometheusMetrics using DefaultBuckets.
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{
+		buckets:     DefaultBuckets,
+		invocations: make(map[string]uint64),
+		clicks:      make(map[string]uint64),
+		latencies:   make(map[string]*histogram),
+		durations:   make(map[string]*histogram),
+		errors:      make(map[[2]string]uint64),
+	}
+}
+
+// WithBuckets changes the upper bounds in seconds of the histogram buckets and returns itself, so that it can be chained.
+// The buckets have to be sorted and should be changed before anything is measured.
+func (m *PrometheusMetrics) WithBuckets(buckets ...float64) *PrometheusMetrics {
+	m.buckets = buckets
+	return m
+}
+
+func (m *PrometheusMetrics) CommandInvoked(command string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.invocations[command]++
+}
+
+func (m *PrometheusMetrics) ComponentClicked(customID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clicks[customID]++
+}
+
+func (m *PrometheusMetrics) Responded(handler string, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observe(m.latencies, handler, latency)
+}
+
+func (m *PrometheusMetrics) HandlerFinished(handler string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observe(m.durations, handler, duration)
+}
+
+func (m *PrometheusMetrics) HandlerError(handler string, kind string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors[[2]string{handler, kind}]++
+}
+
+func (m *PrometheusMetrics) MessageHandlers(count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messageHandlers = count
+}
+
+func (m *PrometheusMetrics) observe(histograms map[string]*histogram, name string, d time.Duration) {
+	h, ok := histograms[name]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		histograms[name] = h
+	}
+
+	seconds := d.Seconds()
+	for n, bound := range m.buckets {
+		if seconds <= bound {
+			h.counts[n]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// A histogram keeps the cumulative count of observations for every bucket.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// WriteTo writes all metrics to w in the Prometheus text format.
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	b := bufio.NewWriter(cw)
+
+	writeHeader(b, "harmonia_command_invocations_total", "counter", "Number of times a command was invoked, by command path.")
+	for _, command := range sortedKeys(m.invocations) {
+		fmt.Fprintf(b, "harmonia_command_invocations_total{command=%v} %v\n", quoteLabel(command), m.invocations[command])
+	}
+
+	writeHeader(b, "harmonia_component_clicks_total", "counter", "Number of times a component with a handler was used, by custom ID.")
+	for _, customID := range sortedKeys(m.clicks) {
+		fmt.Fprintf(b, "harmonia_component_clicks_total{custom_id=%v} %v\n", quoteLabel(customID), m.clicks[customID])
+	}
+
+	writeHeader(b, "harmonia_response_latency_seconds", "histogram", "Time between dispatching an Invocation and its first response, by handler.")
+	m.writeHistograms(b, "harmonia_response_latency_seconds", m.latencies)
+
+	writeHeader(b, "harmonia_handler_duration_seconds", "histogram", "Time a handler ran for after its guards passed, by handler.")
+	m.writeHistograms(b, "harmonia_handler_duration_seconds", m.durations)
+
+	writeHeader(b, "harmonia_handler_errors_total", "counter", "Number of handlers that panicked or failed to respond, by handler and kind.")
+	keys := make([][2]string, 0, len(m.errors))
+	for key := range m.errors {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a][0] != keys[b][0] {
+			return keys[a][0] < keys[b][0]
+		}
+		return keys[a][1] < keys[b][1]
+	})
+	for _, key := range keys {
+		fmt.Fprintf(b, "harmonia_handler_errors_total{handler=%v,kind=%v} %v\n", quoteLabel(key[0]), quoteLabel(key[1]), m.errors[key])
+	}
+
+	writeHeader(b, "harmonia_message_component_handlers", "gauge", "Number of component handlers added to InteractionMessages.")
+	fmt.Fprintf(b, "harmonia_message_component_handlers %v\n", m.messageHandlers)
+
+	err := b.Flush()
+	return cw.n, err
+}
+
+func (m *PrometheusMetrics) writeHistograms(w io.Writer, name string, histograms map[string]*histogram) {
+	for _, handler := range sortedKeys(histograms) {
+		h := histograms[handler]
+		label := quoteLabel(handler)
+		for n, bound := range m.buckets {
+			fmt.Fprintf(w, "%v_bucket{handler=%v,le=\"%v\"} %v\n", name, label, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[n])
+		}
+		fmt.Fprintf(w, "%v_bucket{handler=%v,le=\"+Inf\"} %v\n", name, label, h.count)
+		fmt.Fprintf(w, "%v_sum{handler=%v} %v\n", name, label, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "%v_count{handler=%v} %v\n", name, label, h.count)
+	}
+}
+
+// ServeHTTP writes all metrics in the Prometheus text format as the response.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+func writeHeader(w io.Writer, name, t, help string) {
+	fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n", name, help, name, t)
+}
+
+// quoteLabel quotes a label value, escaping backslashes, double quotes and newlines like the Prometheus text format requires.
+func quoteLabel(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,208 @@
+package harmonia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A Reaction describes a reaction that was added to or removed from a message with a reaction handler.
+type Reaction struct {
+	*discordgo.MessageReaction
+	// Added is true when the reaction was added, and false when it was removed.
+	Added bool
+	// Author is the user that added or removed the reaction. Discord only sends the ID of the user that removed a reaction,
+	// so it is only resolved further when the user is in the State cache.
+	Author *Author
+}
+
+// A ReactionFunc handles a Reaction.
+type ReactionFunc func(h *Harmonia, r *Reaction)
+
+type reactionHandler struct {
+	handler ReactionFunc
+	timer   *time.Timer
+}
+
+type reactionWaiter struct {
+	messageID string
+	emoji     string
+	filter    func(r *Reaction) bool
+	reactions chan *Reaction
+}
+
+// reactions keeps the reaction handlers and the waiters of AwaitReaction, as they are added from handlers while reactions are dispatched.
+type reactions struct {
+	mu       sync.Mutex
+	handlers map[string]*reactionHandler
+	waiters  []*reactionWaiter
+}
+
+func reactionKey(messageID, emoji string) string {
+	return messageID + "-" + emoji
+}
+
+// AddReactionHandler adds a handler for reactions with the given emoji on a message, which is called both when one is added and when one is removed.
+// The emoji is the unicode emoji, or "name:id" for a custom emoji. An empty emoji matches every emoji that has no handler of its own.
+// When ttl is not 0, the handler is removed once that much time has passed.
+func (h *Harmonia) AddReactionHandler(messageID, emoji string, ttl time.Duration, handler ReactionFunc) error {
+	if messageID == "" {
+		return errors.New("empty message ID")
+	}
+
+	h.reactions.mu.Lock()
+	defer h.reactions.mu.Unlock()
+
+	key := reactionKey(messageID, emoji)
+	if _, ok := h.reactions.handlers[key]; ok {
+		return fmt.Errorf("emoji '%v' already has a handler on message '%v'", emoji, messageID)
+	}
+
+	if h.reactions.handlers == nil {
+		h.reactions.handlers = make(map[string]*reactionHandler)
+	}
+	r := &reactionHandler{handler: handler}
+	if ttl > 0 {
+		r.timer = time.AfterFunc(ttl, func() {
+			h.reactions.mu.Lock()
+			defer h.reactions.mu.Unlock()
+			if h.reactions.handlers[key] == r {
+				delete(h.reactions.handlers, key)
+			}
+		})
+	}
+	h.reactions.handlers[key] = r
+	return nil
+}
+
+// AddReactionHandlerToInteractionMessage does the same as AddReactionHandler, for reactions on an InteractionMessage.
+func (h *Harmonia) AddReactionHandlerToInteractionMessage(f *InteractionMessage, emoji string, ttl time.Duration, handler ReactionFunc) error {
+	if err := checkInteractionMessage(f); err != nil {
+		return err
+	}
+	return h.AddReactionHandler(f.ID, emoji, ttl, handler)
+}
+
+// RemoveReactionHandler removes the handler for reactions with the given emoji on a message.
+func (h *Harmonia) RemoveReactionHandler(messageID, emoji string) error {
+	h.reactions.mu.Lock()
+	defer h.reactions.mu.Unlock()
+
+	key := reactionKey(messageID, emoji)
+	r, ok := h.reactions.handlers[key]
+	if !ok {
+		return fmt.Errorf("emoji '%v' has no handler on message '%v'", emoji, messageID)
+	}
+
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	delete(h.reactions.handlers, key)
+	return nil
+}
+
+// AwaitReaction blocks until a reaction with the given emoji is added to a message by a user for which filter returns true, or until ctx is done.
+// An empty emoji matches every emoji and a nil filter matches every reaction. When ctx is done first, its error is returned.
+//
+// AwaitReaction is meant to be called from handlers, which run in their own goroutine, so give ctx a deadline: a handler that is waiting keeps Wait blocked.
+// Reaction handlers of the message are still called.
+func (h *Harmonia) AwaitReaction(ctx context.Context, messageID, emoji string, filter func(r *Reaction) bool) (*Reaction, error) {
+	w := &reactionWaiter{messageID: messageID, emoji: emoji, filter: filter, reactions: make(chan *Reaction, 1)}
+
+	h.reactions.mu.Lock()
+	h.reactions.waiters = append(h.reactions.waiters, w)
+	h.reactions.mu.Unlock()
+
+	select {
+	case r := <-w.reactions:
+		return r, nil
+	case <-ctx.Done():
+		h.reactions.mu.Lock()
+		h.removeWaiter(w)
+		h.reactions.mu.Unlock()
+
+		// A reaction may have arrived right before the waiter was removed.
+		select {
+		case r := <-w.reactions:
+			return r, nil
+		default:
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// removeWaiter removes a waiter of AwaitReaction, returning whether it was still waiting. The lock of the reactions has to be held.
+func (h *Harmonia) removeWaiter(w *reactionWaiter) bool {
+	for n, other := range h.reactions.waiters {
+		if other == w {
+			h.reactions.waiters = append(h.reactions.waiters[:n:n], h.reactions.waiters[n+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// dispatchReaction calls the reaction handler and waiters for a reaction that was added or removed.
+func (h *Harmonia) dispatchReaction(name string, reaction *discordgo.MessageReaction, added bool, member *discordgo.Member, user *discordgo.User) {
+	emoji := reaction.Emoji.APIName()
+
+	h.reactions.mu.Lock()
+	r, ok := h.reactions.handlers[reactionKey(reaction.MessageID, emoji)]
+	if !ok {
+		r, ok = h.reactions.handlers[reactionKey(reaction.MessageID, "")]
+	}
+	waiting := false
+	for _, w := range h.reactions.waiters {
+		waiting = waiting || (added && w.messageID == reaction.MessageID)
+	}
+	h.reactions.mu.Unlock()
+
+	if !ok && !waiting {
+		return
+	}
+
+	if member != nil && member.User != nil {
+		user = member.User
+	}
+	if user != nil && user.ID != "" && user.ID == h.appID() {
+		return
+	}
+
+	i := h.newEventInvocation(name, reaction.GuildID, reaction.ChannelID, member, user)
+	h.logger().Debug("dispatching reaction", i.logFields("message_id", reaction.MessageID, "emoji", emoji)...)
+	h.startInvocationSpan(i, "harmonia.reaction", "message_id", reaction.MessageID, "emoji", emoji)
+	h.invoke(i, nil, func(h *Harmonia, i *Invocation) {
+		react := &Reaction{MessageReaction: reaction, Added: added, Author: i.eventAuthor()}
+		if added {
+			h.notifyWaiters(react, emoji)
+		}
+		if ok {
+			r.handler(h, react)
+		}
+	})
+}
+
+// notifyWaiters hands the Reaction to the first waiter of AwaitReaction it matches. Filters are called without holding the lock.
+func (h *Harmonia) notifyWaiters(r *Reaction, emoji string) {
+	h.reactions.mu.Lock()
+	waiters := append([]*reactionWaiter(nil), h.reactions.waiters...)
+	h.reactions.mu.Unlock()
+
+	for _, w := range waiters {
+		if w.messageID != r.MessageID || (w.emoji != "" && w.emoji != emoji) || (w.filter != nil && !w.filter(r)) {
+			continue
+		}
+
+		h.reactions.mu.Lock()
+		waiting := h.removeWaiter(w)
+		h.reactions.mu.Unlock()
+		if waiting {
+			w.reactions <- r
+			return
+		}
+	}
+}
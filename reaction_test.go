@@ -0,0 +1,82 @@
+package harmonia
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func reactionAdd(messageID, emoji, userID string) *discordgo.MessageReactionAdd {
+	return &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+		UserID:    userID,
+		MessageID: messageID,
+		Emoji:     discordgo.Emoji{Name: emoji},
+	}}
+}
+
+func TestReactionHandlers(t *testing.T) {
+	harm := &Harmonia{}
+	reactions := make(chan string, 10)
+	handler := func(h *Harmonia, r *Reaction) {
+		reactions <- r.Emoji.Name + " by " + r.Author.ID
+	}
+
+	assert.Nil(t, harm.AddReactionHandler("message", "👍", 0, handler))
+	assert.Nil(t, harm.AddReactionHandler("message", "", 0, handler))
+	assert.NotNil(t, harm.AddReactionHandler("message", "👍", 0, handler))
+	assert.Nil(t, harm.AddReactionHandler("expiring", "👍", 10*time.Millisecond, handler))
+
+	harm.DispatchEvent(reactionAdd("message", "👍", "alice"))
+	harm.DispatchEvent(reactionAdd("message", "👎", "bob"))
+	harm.DispatchEvent(reactionAdd("other", "👍", "bob"))
+	harm.Wait()
+	assert.Len(t, reactions, 2)
+	assert.ElementsMatch(t, []string{"👍 by alice", "👎 by bob"}, []string{<-reactions, <-reactions})
+
+	assert.Nil(t, harm.RemoveReactionHandler("message", "👍"))
+	assert.NotNil(t, harm.RemoveReactionHandler("message", "👍"))
+
+	time.Sleep(20 * time.Millisecond)
+	harm.DispatchEvent(reactionAdd("expiring", "👍", "alice"))
+	harm.Wait()
+	assert.Len(t, reactions, 0, "the handler has expired")
+}
+
+func TestAwaitReaction(t *testing.T) {
+	harm := &Harmonia{}
+
+	awaited := make(chan *Reaction)
+	go func() {
+		r, _ := harm.AwaitReaction(context.Background(), "message", "✅", func(r *Reaction) bool {
+			return r.Author.ID == "alice"
+		})
+		awaited <- r
+	}()
+	for {
+		harm.reactions.mu.Lock()
+		waiting := len(harm.reactions.waiters) > 0
+		harm.reactions.mu.Unlock()
+		if waiting {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	harm.DispatchEvent(reactionAdd("message", "✅", "bob"))
+	harm.DispatchEvent(reactionAdd("message", "❌", "alice"))
+	harm.DispatchEvent(reactionAdd("message", "✅", "alice"))
+	r := <-awaited
+	assert.Equal(t, "alice", r.Author.ID)
+	assert.Equal(t, "✅", r.Emoji.Name)
+	harm.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	r, err := harm.AwaitReaction(ctx, "message", "", nil)
+	assert.Nil(t, r)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Len(t, harm.reactions.waiters, 0)
+}
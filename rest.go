@@ -0,0 +1,60 @@
+package harmonia
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// A RESTClient describes the calls to the Discord REST API that Harmonia makes.
+// By default the Session of Harmonia is used, but any implementation can be plugged in by setting Harmonia.REST,
+// such as a client using another transport, a fake for tests or a recorder.
+type RESTClient interface {
+	InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error
+	InteractionResponse(interaction *discordgo.Interaction, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	InteractionResponseEdit(interaction *discordgo.Interaction, newresp *discordgo.WebhookEdit, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	InteractionResponseDelete(interaction *discordgo.Interaction, options ...discordgo.RequestOption) error
+
+	FollowupMessageCreate(interaction *discordgo.Interaction, wait bool, data *discordgo.WebhookParams, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	FollowupMessageEdit(interaction *discordgo.Interaction, messageID string, data *discordgo.WebhookEdit, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	FollowupMessageDelete(interaction *discordgo.Interaction, messageID string, options ...discordgo.RequestOption) error
+
+	ApplicationCommandCreate(appID string, guildID string, cmd *discordgo.ApplicationCommand, options ...discordgo.RequestOption) (*discordgo.ApplicationCommand, error)
+	ApplicationCommandEdit(appID, guildID, cmdID string, cmd *discordgo.ApplicationCommand, options ...discordgo.RequestOption) (*discordgo.ApplicationCommand, error)
+	ApplicationCommandDelete(appID, guildID, cmdID string, options ...discordgo.RequestOption) error
+	ApplicationCommands(appID, guildID string, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error)
+
+	Guild(guildID string, options ...discordgo.RequestOption) (*discordgo.Guild, error)
+	GuildRoles(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Role, error)
+	GuildMember(guildID, userID string, options ...discordgo.RequestOption) (*discordgo.Member, error)
+	GuildMemberRoleAdd(guildID, userID, roleID string, options ...discordgo.RequestOption) error
+	GuildMemberRoleRemove(guildID, userID, roleID string, options ...discordgo.RequestOption) error
+	Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	ChannelMessage(channelID, messageID string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageEditComplex(m *discordgo.MessageEdit, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error
+	ChannelTyping(channelID string, options ...discordgo.RequestOption) error
+	MessageReactionAdd(channelID, messageID, emojiID string, options ...discordgo.RequestOption) error
+	User(userID string, options ...discordgo.RequestOption) (*discordgo.User, error)
+}
+
+var _ RESTClient = (*discordgo.Session)(nil)
+
+// RESTClient returns the RESTClient Harmonia uses, which is the Session unless REST is set.
+// Packages that make their own calls to the Discord API should use it, so that those calls go through the same client.
+func (h *Harmonia) RESTClient() RESTClient {
+	if h.REST != nil {
+		return h.REST
+	}
+	return h.Session
+}
+
+// appID returns the application ID of the bot, as set on Harmonia or known by the State cache.
+func (h *Harmonia) appID() string {
+	if h.ApplicationID != "" {
+		return h.ApplicationID
+	}
+	if state := h.state(); state != nil && state.User != nil {
+		return state.User.ID
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package harmonia
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingREST is a RESTClient that records the responses made to it. Calls it does not implement panic.
+type recordingREST struct {
+	RESTClient
+	mu        sync.Mutex
+	responses []*discordgo.InteractionResponse
+}
+
+func (r *recordingREST) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses = append(r.responses, resp)
+	return nil
+}
+
+func (r *recordingREST) InteractionResponse(interaction *discordgo.Interaction, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &discordgo.Message{ID: "message", Content: r.responses[len(r.responses)-1].Data.Content}, nil
+}
+
+func TestDispatchWithoutSession(t *testing.T) {
+	rest := &recordingREST{}
+	harm := &Harmonia{
+		REST:              rest,
+		Commands:          make(map[string]CommandHandler),
+		ComponentHandlers: make(map[string]CommandFunc),
+	}
+
+	var msg *InteractionMessage
+	harm.AddCommand(NewSlashCommand("ping").WithDescription("Pong").WithCommand(func(h *Harmonia, i *Invocation) {
+		msg, _ = h.Respond(i, "pong")
+	}))
+
+	harm.Dispatch(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type: discordgo.InteractionApplicationCommand,
+		Data: discordgo.ApplicationCommandInteractionData{Name: "ping"},
+		User: &discordgo.User{ID: "user"},
+	}})
+	harm.Wait()
+
+	assert.Len(t, rest.responses, 1)
+	assert.Equal(t, "pong", rest.responses[0].Data.Content)
+	assert.Equal(t, "pong", msg.Content)
+}
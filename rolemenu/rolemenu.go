@@ -0,0 +1,482 @@
+// Package rolemenu provides self-assignable roles for Harmonia, as messages with a button for every role, a select menu of them, or a reaction for every role.
+//
+// Menus are kept in a Store, so that their component and reaction handlers can be restored with Restore when the bot starts and keep working after a restart.
+// Before a role is given or taken, the bot checks that it has the Manage Roles permission and that the role is below its highest role.
+package rolemenu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Moonlington/harmonia"
+	"github.com/bwmarrin/discordgo"
+)
+
+// A Style describes how the roles of a Menu are shown.
+type Style int
+
+const (
+	// Buttons shows a button for every role, which gives the role or takes it away when clicked.
+	Buttons Style = iota
+	// Select shows a select menu of the roles, the roles that are selected are given and the others taken away.
+	Select
+	// Reactions adds a reaction with the emoji of every role to the message. Reacting with it gives the role, and removing the reaction takes it away.
+	// Every role needs an emoji. There is no Interaction to reply to, so members are not told when a role can not be given.
+	Reactions
+)
+
+const (
+	// maxRoles is the number of buttons or select menu options Discord allows on a message.
+	maxRoles = 25
+	// maxReactions is the number of different emoji Discord allows in the reactions on a message.
+	maxReactions = 20
+)
+
+// A Role is a role that can be picked from a Menu.
+type Role struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	// Emoji is shown next to the label, it is the unicode emoji, or "name:id" for a custom emoji.
+	Emoji string `json:"emoji,omitempty"`
+}
+
+// A Menu describes a message that roles can be picked from.
+type Menu struct {
+	MessageID string  `json:"message_id"`
+	ChannelID string  `json:"channel_id"`
+	GuildID   string  `json:"guild_id"`
+	Style     Style   `json:"style"`
+	Roles     []*Role `json:"roles"`
+}
+
+// validate checks the roles of a Menu with the given Style against the limits of Discord.
+func validate(style Style, roles []*Role) error {
+	if len(roles) == 0 {
+		return errors.New("a role menu needs at least one role")
+	}
+	max := maxRoles
+	if style == Reactions {
+		max = maxReactions
+	}
+	if len(roles) > max {
+		return fmt.Errorf("a role menu has %v roles, at most %v are allowed", len(roles), max)
+	}
+
+	ids := make(map[string]bool, len(roles))
+	emoji := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		if role.ID == "" || role.Label == "" {
+			return errors.New("every role of a role menu needs an ID and a label")
+		}
+		if ids[role.ID] {
+			return fmt.Errorf("role '%v' is in the role menu more than once", role.ID)
+		}
+		ids[role.ID] = true
+
+		if style != Reactions {
+			continue
+		}
+		if role.Emoji == "" {
+			return fmt.Errorf("role '%v' needs an emoji to be reacted with", role.ID)
+		}
+		if emoji[role.Emoji] {
+			return fmt.Errorf("emoji '%v' is in the role menu more than once", role.Emoji)
+		}
+		emoji[role.Emoji] = true
+	}
+	return nil
+}
+
+// Components returns the buttons or select menu of the Menu, a Menu with the Reactions style has none.
+func (m *Menu) Components() []discordgo.MessageComponent {
+	if m.Style == Reactions {
+		return nil
+	}
+	if m.Style == Select {
+		options := make([]discordgo.SelectMenuOption, len(m.Roles))
+		for n, role := range m.Roles {
+			options[n] = discordgo.SelectMenuOption{Label: role.Label, Value: role.ID, Emoji: emoji(role.Emoji)}
+		}
+		zero := 0
+		return harmonia.ParseComponentMatrix([][]discordgo.MessageComponent{{discordgo.SelectMenu{
+			CustomID:    selectCustomID,
+			Placeholder: "Pick your roles",
+			MinValues:   &zero,
+			MaxValues:   len(options),
+			Options:     options,
+		}}})
+	}
+
+	rows := make([][]discordgo.MessageComponent, 0)
+	for n, role := range m.Roles {
+		if n%5 == 0 {
+			rows = append(rows, make([]discordgo.MessageComponent, 0, 5))
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], discordgo.Button{
+			Label:    role.Label,
+			Style:    discordgo.SecondaryButton,
+			CustomID: buttonCustomID(role.ID),
+			Emoji:    emoji(role.Emoji),
+		})
+	}
+	return harmonia.ParseComponentMatrix(rows)
+}
+
+const selectCustomID = "rolemenu"
+
+func buttonCustomID(roleID string) string {
+	return "rolemenu-" + roleID
+}
+
+func emoji(e string) *discordgo.ComponentEmoji {
+	if e == "" {
+		return nil
+	}
+	name, id, _ := strings.Cut(e, ":")
+	return &discordgo.ComponentEmoji{Name: name, ID: id}
+}
+
+// A Manager sends role menus and handles picking roles from them.
+type Manager struct {
+	h     *harmonia.Harmonia
+	store Store
+
+	mu sync.Mutex
+	// handled are the IDs of the messages of the menus that are handled.
+	handled map[string]bool
+}
+
+// New returns a Manager that keeps its menus in the given Store. Call Restore once the commands of the bot are added, to handle the menus that were already sent.
+func New(h *harmonia.Harmonia, store Store) *Manager {
+	return &Manager{h: h, store: store, handled: make(map[string]bool)}
+}
+
+// Send sends a role menu with the given content to a channel of a guild.
+func (m *Manager) Send(guildID, channelID, content string, style Style, roles ...*Role) (*Menu, error) {
+	if err := validate(style, roles); err != nil {
+		return nil, err
+	}
+
+	menu := &Menu{ChannelID: channelID, GuildID: guildID, Style: style, Roles: roles}
+	message, err := m.h.RESTClient().ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:         content,
+		Components:      menu.Components(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	menu.MessageID = message.ID
+	return menu, m.add(menu)
+}
+
+// Respond responds to an Invocation with a role menu with the given content. The Invocation has to happen in a guild.
+func (m *Manager) Respond(i *harmonia.Invocation, content string, style Style, roles ...*Role) (*Menu, error) {
+	if i.GuildID == "" {
+		return nil, harmonia.ErrGuildOnly
+	}
+	if err := validate(style, roles); err != nil {
+		return nil, err
+	}
+
+	menu := &Menu{ChannelID: i.ChannelID, GuildID: i.GuildID, Style: style, Roles: roles}
+	message, err := m.h.RespondComplex(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			Components:      menu.Components(),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	menu.MessageID = message.ID
+	return menu, m.add(menu)
+}
+
+// add saves the Menu in the Store and handles its components or reactions. The bot reacts with the emoji of the roles of a Menu with the Reactions style.
+func (m *Manager) add(menu *Menu) error {
+	if err := m.store.Save(menu); err != nil {
+		return err
+	}
+	if err := m.handle(menu); err != nil {
+		return err
+	}
+
+	if menu.Style != Reactions {
+		return nil
+	}
+	for _, role := range menu.Roles {
+		if err := m.h.RESTClient().MessageReactionAdd(menu.ChannelID, menu.MessageID, role.Emoji); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore handles the components and reactions of every Menu in the Store, so that menus that were sent before the bot restarted keep working.
+// Menus that are already handled are skipped, so Restore can be called again, such as when a Module is loaded again.
+func (m *Manager) Restore() error {
+	menus, err := m.store.Menus()
+	if err != nil {
+		return err
+	}
+
+	for _, menu := range menus {
+		if err := m.handle(menu); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove stops handling the menu on the message with the given ID and removes it from the Store. The message itself is not deleted.
+func (m *Manager) Remove(messageID string) error {
+	menus, err := m.store.Menus()
+	if err != nil {
+		return err
+	}
+
+	for _, menu := range menus {
+		if menu.MessageID != messageID {
+			continue
+		}
+
+		m.unhandle(menu)
+		return m.store.Delete(messageID)
+	}
+	return fmt.Errorf("message '%v' has no role menu", messageID)
+}
+
+// handle adds the component or reaction handlers of the Menu, unless it is already handled.
+func (m *Manager) handle(menu *Menu) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.handled[menu.MessageID] {
+		return nil
+	}
+
+	message := interactionMessage(menu)
+	switch menu.Style {
+	case Select:
+		err := m.h.AddComponentHandlerToInteractionMessage(message, selectCustomID, func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+			m.pick(i, menu, i.Values)
+		})
+		if err != nil {
+			return err
+		}
+	case Reactions:
+		for _, role := range menu.Roles {
+			role := role
+			err := m.h.AddReactionHandler(menu.MessageID, role.Emoji, 0, func(h *harmonia.Harmonia, r *harmonia.Reaction) {
+				m.react(menu, role, r)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		for _, role := range menu.Roles {
+			role := role
+			err := m.h.AddComponentHandlerToInteractionMessage(message, buttonCustomID(role.ID), func(h *harmonia.Harmonia, i *harmonia.Invocation) {
+				m.toggle(i, menu, role)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	m.handled[menu.MessageID] = true
+	return nil
+}
+
+// unhandle removes the component or reaction handlers of the Menu.
+func (m *Manager) unhandle(menu *Menu) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	message := interactionMessage(menu)
+	switch menu.Style {
+	case Select:
+		m.h.RemoveComponentHandlerFromInteractionMessage(message, selectCustomID)
+	case Reactions:
+		for _, role := range menu.Roles {
+			m.h.RemoveReactionHandler(menu.MessageID, role.Emoji)
+		}
+	default:
+		for _, role := range menu.Roles {
+			m.h.RemoveComponentHandlerFromInteractionMessage(message, buttonCustomID(role.ID))
+		}
+	}
+	delete(m.handled, menu.MessageID)
+}
+
+func interactionMessage(menu *Menu) *harmonia.InteractionMessage {
+	return &harmonia.InteractionMessage{Message: &discordgo.Message{ID: menu.MessageID, ChannelID: menu.ChannelID, GuildID: menu.GuildID}}
+}
+
+// toggle gives the role to the invoker if they do not have it yet, and takes it away otherwise.
+func (m *Manager) toggle(i *harmonia.Invocation, menu *Menu, role *Role) {
+	author, err := m.check(i, []*Role{role})
+	if err != nil {
+		m.reply(i, err.Error())
+		return
+	}
+
+	if hasRole(author, role.ID) {
+		if err := m.h.RESTClient().GuildMemberRoleRemove(menu.GuildID, author.ID, role.ID); err != nil {
+			m.reply(i, fmt.Sprintf("Could not take away the role %v.", role.Label))
+			return
+		}
+		m.reply(i, fmt.Sprintf("Took away the role %v.", role.Label))
+		return
+	}
+
+	if err := m.h.RESTClient().GuildMemberRoleAdd(menu.GuildID, author.ID, role.ID); err != nil {
+		m.reply(i, fmt.Sprintf("Could not give you the role %v.", role.Label))
+		return
+	}
+	m.reply(i, fmt.Sprintf("Gave you the role %v.", role.Label))
+}
+
+// pick gives the invoker the roles of the menu that were selected, and takes away the others.
+func (m *Manager) pick(i *harmonia.Invocation, menu *Menu, selected []string) {
+	picked := make(map[string]bool, len(selected))
+	for _, id := range selected {
+		picked[id] = true
+	}
+
+	author, err := i.GetAuthor()
+	if err != nil {
+		m.reply(i, "Could not update your roles.")
+		return
+	}
+
+	changed := make([]*Role, 0)
+	for _, role := range menu.Roles {
+		if picked[role.ID] != hasRole(author, role.ID) {
+			changed = append(changed, role)
+		}
+	}
+	if len(changed) == 0 {
+		m.reply(i, "Your roles are already up to date.")
+		return
+	}
+
+	if _, err := m.check(i, changed); err != nil {
+		m.reply(i, err.Error())
+		return
+	}
+
+	added, removed := make([]string, 0), make([]string, 0)
+	for _, role := range changed {
+		if picked[role.ID] {
+			if err := m.h.RESTClient().GuildMemberRoleAdd(menu.GuildID, author.ID, role.ID); err != nil {
+				m.reply(i, fmt.Sprintf("Could not give you the role %v.", role.Label))
+				return
+			}
+			added = append(added, role.Label)
+		} else {
+			if err := m.h.RESTClient().GuildMemberRoleRemove(menu.GuildID, author.ID, role.ID); err != nil {
+				m.reply(i, fmt.Sprintf("Could not take away the role %v.", role.Label))
+				return
+			}
+			removed = append(removed, role.Label)
+		}
+	}
+
+	lines := make([]string, 0, 2)
+	if len(added) > 0 {
+		lines = append(lines, "Gave you "+strings.Join(added, ", ")+".")
+	}
+	if len(removed) > 0 {
+		lines = append(lines, "Took away "+strings.Join(removed, ", ")+".")
+	}
+	m.reply(i, strings.Join(lines, "\n"))
+}
+
+// react gives the role to the user that added the reaction, or takes it away when the reaction was removed.
+// There is no Interaction to reply to, so when that fails it is only logged.
+func (m *Manager) react(menu *Menu, role *Role, r *harmonia.Reaction) {
+	err := m.checkBot(menu.GuildID, []*Role{role})
+	if err == nil && r.Added {
+		err = m.h.RESTClient().GuildMemberRoleAdd(menu.GuildID, r.UserID, role.ID)
+	} else if err == nil {
+		err = m.h.RESTClient().GuildMemberRoleRemove(menu.GuildID, r.UserID, role.ID)
+	}
+
+	if err != nil && m.h.Logger != nil {
+		m.h.Logger.Warn("could not change role from reaction", "message_id", menu.MessageID, "user_id", r.UserID, "role_id", role.ID, "added", r.Added, "error", err)
+	}
+}
+
+// check returns the Author of the Invocation when the bot can give the roles to it and take them away,
+// or an error explaining to the invoker why it can not.
+func (m *Manager) check(i *harmonia.Invocation, roles []*Role) (*harmonia.Author, error) {
+	author, err := i.GetAuthor()
+	if err != nil || !author.IsMember {
+		return nil, errors.New("roles can only be picked in a server")
+	}
+	if err := m.checkBot(i.GuildID, roles); err != nil {
+		return nil, err
+	}
+	return author, nil
+}
+
+// checkBot returns an error explaining why the bot can not give the roles in the guild, or nil when it can.
+func (m *Manager) checkBot(guildID string, roles []*Role) error {
+	bot, err := harmonia.BotAuthor(m.h, guildID)
+	if err != nil {
+		return errors.New("could not check the permissions of the bot")
+	}
+	if !bot.HasPermission(nil, discordgo.PermissionManageRoles) {
+		return errors.New("the bot needs the Manage Roles permission to give roles")
+	}
+
+	highest := bot.HighestRole()
+	for _, role := range roles {
+		guildRole := findRole(bot.Guild, role.ID)
+		if guildRole == nil {
+			return fmt.Errorf("the role %v no longer exists", role.Label)
+		}
+		if guildRole.Managed || guildRole.ID == guildID {
+			return fmt.Errorf("the role %v can not be given to members", role.Label)
+		}
+		if highest == nil || guildRole.Position >= highest.Position {
+			return fmt.Errorf("the role %v is not below the highest role of the bot", role.Label)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) reply(i *harmonia.Invocation, content string) {
+	m.h.EphemeralRespond(i, content)
+}
+
+func findRole(guild *discordgo.Guild, roleID string) *discordgo.Role {
+	if guild == nil {
+		return nil
+	}
+	for _, role := range guild.Roles {
+		if role.ID == roleID {
+			return role
+		}
+	}
+	return nil
+}
+
+func hasRole(author *harmonia.Author, roleID string) bool {
+	for _, role := range author.Roles {
+		if role.ID == roleID {
+			return true
+		}
+	}
+	return false
+}
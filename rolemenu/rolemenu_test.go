@@ -0,0 +1,135 @@
+package rolemenu_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Moonlington/harmonia/harmoniatest"
+	"github.com/Moonlington/harmonia/rolemenu"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	bots  = &discordgo.Role{ID: "401", Position: 3, Permissions: discordgo.PermissionManageRoles}
+	red   = &discordgo.Role{ID: "402", Position: 1}
+	blue  = &discordgo.Role{ID: "403", Position: 2}
+	admin = &discordgo.Role{ID: "404", Position: 4}
+	guild = &discordgo.Guild{ID: "300", OwnerID: "1", Roles: []*discordgo.Role{{ID: "300"}, bots, red, blue, admin}}
+	alice = &discordgo.User{ID: "2", Username: "alice"}
+)
+
+func newHarness(t *testing.T) *harmoniatest.Harness {
+	hs := harmoniatest.New(t)
+	hs.Backend.AddGuild(guild)
+	hs.Backend.AddMember(guild.ID, &discordgo.Member{User: &discordgo.User{ID: harmoniatest.AppID}, Roles: []string{bots.ID}})
+	return hs
+}
+
+func roles(hs *harmoniatest.Harness) []string {
+	if member := hs.Backend.Member(guild.ID, alice.ID); member != nil {
+		return member.Roles
+	}
+	return nil
+}
+
+func TestButtons(t *testing.T) {
+	hs := newHarness(t)
+	m := rolemenu.New(hs.Harmonia, rolemenu.NewMemoryStore())
+
+	menu, err := m.Send(guild.ID, harmoniatest.ChannelID, "Pick a colour", rolemenu.Buttons,
+		&rolemenu.Role{ID: red.ID, Label: "Red"}, &rolemenu.Role{ID: admin.ID, Label: "Admin"})
+	assert.NoError(t, err)
+	assert.Len(t, hs.Backend.Message(menu.MessageID).Components, 1)
+
+	r := hs.SimulateComponent(menu.MessageID, "rolemenu-"+red.ID, nil, harmoniatest.Member(guild, alice))
+	assert.Equal(t, "Gave you the role Red.", r.Response().Message.Content)
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, r.Response().Message.Flags)
+	assert.Equal(t, []string{red.ID}, roles(hs))
+
+	r = hs.SimulateComponent(menu.MessageID, "rolemenu-"+red.ID, nil, harmoniatest.Member(guild, alice, red))
+	assert.Equal(t, "Took away the role Red.", r.Response().Message.Content)
+	assert.Empty(t, roles(hs))
+
+	r = hs.SimulateComponent(menu.MessageID, "rolemenu-"+admin.ID, nil, harmoniatest.Member(guild, alice))
+	assert.Equal(t, "the role Admin is not below the highest role of the bot", r.Response().Message.Content)
+	assert.Empty(t, roles(hs))
+
+	assert.NoError(t, m.Remove(menu.MessageID))
+	assert.Error(t, m.Remove(menu.MessageID))
+}
+
+func TestSelect(t *testing.T) {
+	hs := newHarness(t)
+	m := rolemenu.New(hs.Harmonia, rolemenu.NewMemoryStore())
+
+	menu, err := m.Send(guild.ID, harmoniatest.ChannelID, "Pick colours", rolemenu.Select,
+		&rolemenu.Role{ID: red.ID, Label: "Red"}, &rolemenu.Role{ID: blue.ID, Label: "Blue"})
+	assert.NoError(t, err)
+
+	r := hs.SimulateComponent(menu.MessageID, "rolemenu", []string{blue.ID}, harmoniatest.Member(guild, alice, red))
+	assert.Equal(t, "Gave you Blue.\nTook away Red.", r.Response().Message.Content)
+	assert.Equal(t, []string{blue.ID}, roles(hs))
+
+	r = hs.SimulateComponent(menu.MessageID, "rolemenu", []string{blue.ID}, harmoniatest.Member(guild, alice, blue))
+	assert.Equal(t, "Your roles are already up to date.", r.Response().Message.Content)
+}
+
+func TestReactions(t *testing.T) {
+	hs := newHarness(t)
+	m := rolemenu.New(hs.Harmonia, rolemenu.NewMemoryStore())
+
+	menu, err := m.Send(guild.ID, harmoniatest.ChannelID, "React for a colour", rolemenu.Reactions,
+		&rolemenu.Role{ID: red.ID, Label: "Red", Emoji: "🟥"}, &rolemenu.Role{ID: admin.ID, Label: "Admin", Emoji: "crown:405"})
+	assert.NoError(t, err)
+	message := hs.Backend.Message(menu.MessageID)
+	assert.Empty(t, message.Components)
+	assert.Len(t, message.Reactions, 2)
+	assert.Equal(t, "crown:405", message.Reactions[1].Emoji.APIName())
+
+	hs.SimulateReactionAdd(menu.MessageID, "🟥", harmoniatest.Member(guild, alice))
+	assert.Equal(t, []string{red.ID}, roles(hs))
+
+	hs.SimulateReactionAdd(menu.MessageID, "crown:405", harmoniatest.Member(guild, alice))
+	assert.Equal(t, []string{red.ID}, roles(hs), "the role is not below the highest role of the bot")
+
+	hs.SimulateReactionRemove(menu.MessageID, "🟥", harmoniatest.Member(guild, alice, red))
+	assert.Empty(t, roles(hs))
+
+	assert.NoError(t, m.Remove(menu.MessageID))
+	hs.SimulateReactionAdd(menu.MessageID, "🟥", harmoniatest.Member(guild, alice))
+	assert.Empty(t, roles(hs))
+}
+
+func TestRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "menus.json")
+
+	hs := newHarness(t)
+	menu, err := rolemenu.New(hs.Harmonia, rolemenu.NewFileStore(path)).Send(guild.ID, harmoniatest.ChannelID, "Pick a colour", rolemenu.Buttons,
+		&rolemenu.Role{ID: red.ID, Label: "Red", Emoji: "🟥"})
+	assert.NoError(t, err)
+
+	restarted := newHarness(t)
+	m := rolemenu.New(restarted.Harmonia, rolemenu.NewFileStore(path))
+	assert.NoError(t, m.Restore())
+	assert.NoError(t, m.Restore(), "menus that are handled already are skipped")
+
+	r := restarted.SimulateComponent(menu.MessageID, "rolemenu-"+red.ID, nil, harmoniatest.Member(guild, alice))
+	assert.Equal(t, "Gave you the role Red.", r.Response().Message.Content)
+	assert.Equal(t, []string{red.ID}, roles(restarted))
+}
+
+func TestValidate(t *testing.T) {
+	hs := newHarness(t)
+	m := rolemenu.New(hs.Harmonia, rolemenu.NewMemoryStore())
+
+	_, err := m.Send(guild.ID, harmoniatest.ChannelID, "Nothing", rolemenu.Buttons)
+	assert.Error(t, err)
+
+	_, err = m.Send(guild.ID, harmoniatest.ChannelID, "Twice", rolemenu.Buttons,
+		&rolemenu.Role{ID: red.ID, Label: "Red"}, &rolemenu.Role{ID: red.ID, Label: "Also red"})
+	assert.EqualError(t, err, "role '402' is in the role menu more than once")
+
+	_, err = m.Send(guild.ID, harmoniatest.ChannelID, "No emoji", rolemenu.Reactions, &rolemenu.Role{ID: red.ID, Label: "Red"})
+	assert.EqualError(t, err, "role '402' needs an emoji to be reacted with")
+}
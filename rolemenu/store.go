@@ -0,0 +1,152 @@
+package rolemenu
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+)
+
+// A Store keeps the menus a Manager has sent, so that they can be restored when the bot starts.
+// Methods can be called from multiple goroutines at once.
+type Store interface {
+	// Save saves a Menu, replacing the one on the same message if there is one.
+	Save(menu *Menu) error
+	// Delete deletes the Menu on the message with the given ID. Deleting a Menu that does not exist is not an error.
+	Delete(messageID string) error
+	// Menus returns every Menu that was saved.
+	Menus() ([]*Menu, error)
+}
+
+// NewMemoryStore returns a MemoryStore without any menus.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{menus: make(map[string]*Menu)}
+}
+
+// A MemoryStore is a Store that keeps menus in memory, so they do not survive a restart. It is meant for tests, and for bots that send their menus again when they start.
+type MemoryStore struct {
+	mu    sync.Mutex
+	menus map[string]*Menu
+}
+
+func (s *MemoryStore) Save(menu *Menu) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.menus[menu.MessageID] = menu
+	return nil
+}
+
+func (s *MemoryStore) Delete(messageID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.menus, messageID)
+	return nil
+}
+
+// Menus returns the menus ordered by the ID of their message, which is the order they were sent in.
+func (s *MemoryStore) Menus() ([]*Menu, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedMenus(s.menus), nil
+}
+
+// NewFileStore returns a FileStore that keeps its menus in the JSON file at the given path. The file is created when the first Menu is saved.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// A FileStore is a Store that keeps menus in a JSON file.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (s *FileStore) Save(menu *Menu) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	menus, err := s.read()
+	if err != nil {
+		return err
+	}
+	menus[menu.MessageID] = menu
+	return s.write(menus)
+}
+
+func (s *FileStore) Delete(messageID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	menus, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := menus[messageID]; !ok {
+		return nil
+	}
+	delete(menus, messageID)
+	return s.write(menus)
+}
+
+// Menus returns the menus ordered by the ID of their message, which is the order they were sent in.
+func (s *FileStore) Menus() ([]*Menu, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	menus, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return sortedMenus(menus), nil
+}
+
+func (s *FileStore) read() (map[string]*Menu, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[string]*Menu), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*Menu
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+
+	menus := make(map[string]*Menu, len(list))
+	for _, menu := range list {
+		menus[menu.MessageID] = menu
+	}
+	return menus, nil
+}
+
+// write replaces the file with the menus, by writing them to a temporary file first so that a crash can not leave it half written.
+func (s *FileStore) write(menus map[string]*Menu) error {
+	data, err := json.MarshalIndent(sortedMenus(menus), "", "\t")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func sortedMenus(menus map[string]*Menu) []*Menu {
+	list := make([]*Menu, 0, len(menus))
+	for _, menu := range menus {
+		list = append(list, menu)
+	}
+	// Snowflakes of the same length sort by the time they were created.
+	sort.Slice(list, func(a, b int) bool {
+		if len(list[a].MessageID) != len(list[b].MessageID) {
+			return len(list[a].MessageID) < len(list[b].MessageID)
+		}
+		return list[a].MessageID < list[b].MessageID
+	})
+	return list
+}
@@ -16,6 +16,7 @@ type SlashCommand struct {
 	guildID            string
 	dmPermission       bool
 	defaultPermissions *int64
+	guards             []Guard
 
 	commandFunc CommandFunc
 	options     []*Option
@@ -23,21 +24,22 @@ type SlashCommand struct {
 	registration *discordgo.ApplicationCommand
 }
 
-// NewSlashCommand returns a SlashCommand with a given name
+// NewSlashCommand returns a SlashCommand with a given name.
+// The name is validated when the SlashCommand is added with AddCommand, see MustNewSlashCommand to validate it right away.
 func NewSlashCommand(name string) *SlashCommand {
-	if name == "" {
-		log.Panic("empty command name")
-	}
-
-	if !slashCommandNameRegex.MatchString(name) {
-		log.Panic("slash command name does not match with the CHAT_INPUT regex.")
-	}
-
 	return &SlashCommand{
 		name: name,
 	}
 }
 
+// MustNewSlashCommand does the same as NewSlashCommand, but panics if the name is not valid.
+func MustNewSlashCommand(name string) *SlashCommand {
+	if err := validateChatName("command", name); err != nil {
+		log.Panic(err)
+	}
+	return NewSlashCommand(name)
+}
+
 // WithDescription changes the description of the SlashCommand and returns itself, so that it can be chained.
 func (s *SlashCommand) WithDescription(description string) *SlashCommand {
 	s.description = description
@@ -74,19 +76,43 @@ func (s *SlashCommand) WithOptions(options ...*Option) *SlashCommand {
 	return s
 }
 
+// WithGuards adds guards that have to pass before the SlashCommand is executed and returns itself, so that it can be chained.
+func (s *SlashCommand) WithGuards(guards ...Guard) *SlashCommand {
+	s.guards = append(s.guards, guards...)
+	return s
+}
+
+// WithBotPermissions makes the SlashCommand check if the bot has the given permissions before it is executed and returns itself, so that it can be chained.
+func (s *SlashCommand) WithBotPermissions(perms int64) *SlashCommand {
+	return s.WithGuards(RequireBotPermissions(perms))
+}
+
 func (s *SlashCommand) GetName() string {
 	return s.name
 }
 
 func (s *SlashCommand) Do(h *Harmonia, i *Invocation) {
-	go s.commandFunc(h, i)
+	h.invoke(i, s.guards, s.commandFunc)
+}
+
+// validate checks the description and options of the SlashCommand against the limits of Discord. Its name is checked by the caller,
+// as the rules differ between commands and subcommands.
+func (s *SlashCommand) validate() []error {
+	errs := make([]error, 0)
+	if err := validateDescription(s.name, s.description); err != nil {
+		errs = append(errs, err)
+	}
+	return append(errs, validateOptions(s.name, s.options, false)...)
 }
 
 func (s *SlashCommand) getRegistration() *discordgo.ApplicationCommand {
 	if s.registration != nil {
 		return s.registration
 	}
+	return s.buildRegistration()
+}
 
+func (s *SlashCommand) buildRegistration() *discordgo.ApplicationCommand {
 	options := make([]*discordgo.ApplicationCommandOption, len(s.options))
 	for i, v := range s.options {
 		options[i] = v.ApplicationCommandOption
@@ -0,0 +1,681 @@
+package harmonia
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A TextCommand describes a command that is invoked by sending a message starting with a prefix, such as "!ping".
+// Its arguments are converted to the types of its options, so the same CommandFunc can be used for a TextCommand and a SlashCommand.
+type TextCommand struct {
+	name        string
+	aliases     []string
+	description string
+	guards      []Guard
+
+	commandFunc CommandFunc
+	options     []*Option
+
+	// handler is the SlashCommand or GroupSlashCommand executed instead of commandFunc, when added with AddHybridCommand.
+	handler CommandHandler
+}
+
+// NewTextCommand returns a TextCommand with a given name.
+// The name is validated when the TextCommand is added with AddTextCommand, see MustNewTextCommand to validate it right away.
+func NewTextCommand(name string) *TextCommand {
+	return &TextCommand{
+		name: name,
+	}
+}
+
+// MustNewTextCommand does the same as NewTextCommand, but panics if the name is not valid.
+func MustNewTextCommand(name string) *TextCommand {
+	if err := validateTextName(name); err != nil {
+		log.Panic(err)
+	}
+	return NewTextCommand(name)
+}
+
+// WithDescription changes the description of the TextCommand and returns itself, so that it can be chained.
+func (c *TextCommand) WithDescription(description string) *TextCommand {
+	c.description = description
+	return c
+}
+
+// WithAliases adds other names the TextCommand can be invoked with and returns itself, so that it can be chained.
+func (c *TextCommand) WithAliases(aliases ...string) *TextCommand {
+	c.aliases = append(c.aliases, aliases...)
+	return c
+}
+
+// WithGuards adds guards that have to pass before the TextCommand is executed and returns itself, so that it can be chained.
+func (c *TextCommand) WithGuards(guards ...Guard) *TextCommand {
+	c.guards = append(c.guards, guards...)
+	return c
+}
+
+// WithBotPermissions makes the TextCommand check if the bot has the given permissions before it is executed and returns itself, so that it can be chained.
+func (c *TextCommand) WithBotPermissions(perms int64) *TextCommand {
+	return c.WithGuards(RequireBotPermissions(perms))
+}
+
+// WithCommand changes the CommandFunc that is called when the TextCommand is executed and returns itself, so that it can be chained.
+func (c *TextCommand) WithCommand(commandFunc CommandFunc) *TextCommand {
+	c.commandFunc = commandFunc
+	return c
+}
+
+// WithOptions changes the options in the TextCommand and returns itself, so that it can be chained.
+// Arguments are matched to the options in order, unless they are given by name such as "reason:spam".
+// When the last option that is not given by name is a string, it takes all remaining arguments.
+func (c *TextCommand) WithOptions(options ...*Option) *TextCommand {
+	c.options = options
+	return c
+}
+
+// GetName returns the name of the TextCommand.
+func (c *TextCommand) GetName() string {
+	return c.name
+}
+
+// Usage returns how the TextCommand should be invoked with the given prefix, such as "!ban <user> [reason]".
+func (c *TextCommand) Usage(prefix string) string {
+	if c.handler != nil {
+		_, name, options, _ := parseHybrid(c.name, "", nil, c.handler)
+		return prefix + usage(name, options)
+	}
+	return prefix + usage(c.name, c.options)
+}
+
+// validate checks the names and options of the TextCommand. The options follow the rules of slash commands,
+// so the same options can be shared with a SlashCommand.
+func (c *TextCommand) validate() []error {
+	errs := make([]error, 0)
+	names := make(map[string]bool, len(c.aliases)+1)
+	for _, name := range append([]string{c.name}, c.aliases...) {
+		if err := validateTextName(name); err != nil {
+			errs = append(errs, err)
+		} else if names[name] {
+			errs = append(errs, fmt.Errorf("text command '%v' has more than one alias named '%v'", c.name, name))
+		}
+		names[name] = true
+	}
+
+	// The options of a hybrid command are those of its handler, which were validated when it was added as a command.
+	if c.handler != nil {
+		return errs
+	}
+	return append(errs, validateOptions(c.name, c.options, true)...)
+}
+
+// Do executes the TextCommand.
+func (c *TextCommand) Do(h *Harmonia, i *Invocation) {
+	if c.handler != nil {
+		c.handler.Do(h, i)
+		return
+	}
+	h.invoke(i, c.guards, c.commandFunc)
+}
+
+// parse converts the arguments to options, it returns how the TextCommand should be invoked when they are invalid.
+func (c *TextCommand) parse(prefix, content string, args []arg) ([]*discordgo.ApplicationCommandInteractionDataOption, string, error) {
+	if c.handler != nil {
+		options, name, usageOptions, err := parseHybrid(c.name, content, args, c.handler)
+		return options, prefix + usage(name, usageOptions), err
+	}
+
+	options, err := parseArgs(content, args, c.options)
+	return options, c.Usage(prefix), err
+}
+
+func usage(name string, options []*Option) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, option := range options {
+		if option.Required {
+			fmt.Fprintf(&b, " <%v>", option.Name)
+		} else {
+			fmt.Fprintf(&b, " [%v]", option.Name)
+		}
+	}
+	return b.String()
+}
+
+// A PrefixFunc returns the prefixes that TextCommands can be invoked with for a message.
+// As it receives the message, it can return different prefixes per guild.
+type PrefixFunc func(h *Harmonia, m *discordgo.Message) []string
+
+// StaticPrefix returns a PrefixFunc that always returns the given prefixes.
+func StaticPrefix(prefixes ...string) PrefixFunc {
+	return func(h *Harmonia, m *discordgo.Message) []string {
+		return prefixes
+	}
+}
+
+// MentionPrefix is a PrefixFunc that allows TextCommands to be invoked by mentioning the bot, such as "@Bot ping".
+func MentionPrefix(h *Harmonia, m *discordgo.Message) []string {
+	id := h.appID()
+	if id == "" {
+		return nil
+	}
+	return []string{"<@" + id + ">", "<@!" + id + ">"}
+}
+
+// Prefixes returns a PrefixFunc that returns the prefixes of all given PrefixFuncs, such as a static prefix together with MentionPrefix.
+func Prefixes(prefixFuncs ...PrefixFunc) PrefixFunc {
+	return func(h *Harmonia, m *discordgo.Message) []string {
+		prefixes := make([]string, 0)
+		for _, prefixFunc := range prefixFuncs {
+			prefixes = append(prefixes, prefixFunc(h, m)...)
+		}
+		return prefixes
+	}
+}
+
+// AddTextCommand adds a TextCommand to Harmonia. A prefix has to be set with Harmonia.Prefix for it to be invoked.
+// The TextCommand is validated first, returning a ValidationError with every problem that was found.
+func (h *Harmonia) AddTextCommand(command *TextCommand) error {
+	if err := newValidationError(command.validate()); err != nil {
+		return err
+	}
+
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+
+	names := append([]string{command.name}, command.aliases...)
+	for _, name := range names {
+		if _, ok := h.TextCommands[name]; ok {
+			return fmt.Errorf("text command '%v' already exists", name)
+		}
+	}
+
+	for _, name := range names {
+		h.TextCommands[name] = command
+	}
+	return nil
+}
+
+// RemoveTextCommand removes a TextCommand and its aliases from Harmonia.
+func (h *Harmonia) RemoveTextCommand(name string) error {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+
+	command, ok := h.TextCommands[name]
+	if !ok {
+		return fmt.Errorf("text command '%v' was not found", name)
+	}
+
+	for _, name := range append([]string{command.name}, command.aliases...) {
+		delete(h.TextCommands, name)
+	}
+	return nil
+}
+
+// hasTextCommands returns whether any TextCommand was added.
+func (h *Harmonia) hasTextCommands() bool {
+	h.handlersMu.RLock()
+	defer h.handlersMu.RUnlock()
+	return len(h.TextCommands) > 0
+}
+
+// textCommand returns the TextCommand with the given name or alias, which is matched case-insensitively when there is no exact match.
+func (h *Harmonia) textCommand(name string) (*TextCommand, bool) {
+	h.handlersMu.RLock()
+	defer h.handlersMu.RUnlock()
+	if command, ok := h.TextCommands[name]; ok {
+		return command, true
+	}
+	command, ok := h.TextCommands[strings.ToLower(name)]
+	return command, ok
+}
+
+// DispatchMessage handles an incoming message, executing the TextCommand it invokes if it starts with one of the prefixes.
+// Run calls DispatchMessage for every message received from the gateway.
+func (h *Harmonia) DispatchMessage(m *discordgo.MessageCreate) {
+	if h.Prefix == nil || !h.hasTextCommands() || m.Author == nil || m.Author.Bot {
+		return
+	}
+
+	content, prefix, ok := trimPrefix(m.Content, h.Prefix(h, m.Message))
+	if !ok {
+		return
+	}
+
+	args, err := splitArgs(content)
+	if err != nil || len(args) == 0 {
+		return
+	}
+
+	command, ok := h.textCommand(args[0].value)
+	if !ok {
+		return
+	}
+
+	i := h.newTextInvocation(m.Message)
+	h.startInvocationSpan(i, "harmonia.text_command", "text_command", command.name)
+	if h.EagerResolve {
+		i.resolve()
+	}
+	h.resolvePermissions(i)
+	options, usage, err := command.parse(prefix, content, args[1:])
+	if err != nil {
+		h.logger().Debug("invalid text command arguments", i.logFields("text_command", command.name, "error", err)...)
+		i.span.RecordError(err)
+		h.replyError(i, fmt.Sprintf("%v\nUsage: `%v`", err, usage))
+		i.endInvocationSpan()
+		return
+	}
+	i.options = options
+	if command.handler != nil {
+		i.setCommand(command.handler, h.registration(command.handler).ID, options)
+	} else {
+		i.CommandPath = []string{command.name}
+	}
+
+	h.logger().Debug("dispatching text command", i.logFields()...)
+	h.metrics().CommandInvoked(i.handlerName())
+	i.span.SetAttributes("command", i.handlerName())
+	command.Do(h, i)
+	if !i.invoked {
+		i.endInvocationSpan()
+	}
+}
+
+// trimPrefix removes the longest matching prefix from the content.
+func trimPrefix(content string, prefixes []string) (string, string, bool) {
+	match := ""
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(content, prefix) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+
+	if match == "" {
+		return content, "", false
+	}
+	return strings.TrimSpace(content[len(match):]), match, true
+}
+
+// newTextInvocation creates an Invocation from a message.
+// An Interaction is filled in from the message, so that handlers can be shared between TextCommands and application commands.
+// Its permissions are filled in by resolvePermissions, once its span is started.
+func (h *Harmonia) newTextInvocation(m *discordgo.Message) *Invocation {
+	interaction := &discordgo.Interaction{
+		ID:        m.ID,
+		AppID:     h.appID(),
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+	}
+
+	if m.Member != nil && m.GuildID != "" {
+		member := *m.Member
+		member.User = m.Author
+		member.GuildID = m.GuildID
+		interaction.Member = &member
+	} else {
+		interaction.User = m.Author
+	}
+
+	return &Invocation{Interaction: interaction, TextMessage: m, h: h, dispatched: time.Now()}
+}
+
+// resolvePermissions fills in the permissions of the invoker and the bot of an Invocation that does not come from an Interaction,
+// which Discord would otherwise have included.
+func (h *Harmonia) resolvePermissions(i *Invocation) {
+	if i.GuildID == "" {
+		i.AppPermissions = discordgo.PermissionAllText
+		return
+	}
+
+	// Some events in a guild, such as a reaction being removed, have no member. The permissions of the bot are computed all the same,
+	// and left at zero when the bot can not be found, so that checks of them fail.
+	if i.Member != nil {
+		i.Member.Permissions = h.memberPermissions(i, i.Member)
+	}
+	if bot, err := h.botMember(i.GuildID); err == nil {
+		i.AppPermissions = h.memberPermissions(i, bot)
+	}
+}
+
+// memberPermissions computes the permissions of a member in the channel of the Invocation, as messages do not include them like Interactions do.
+func (h *Harmonia) memberPermissions(i *Invocation, member *discordgo.Member) int64 {
+	guild, err := i.GetGuild()
+	if err != nil {
+		return 0
+	}
+
+	author, err := authorFromMember(h, guild, member)
+	if err != nil {
+		return 0
+	}
+
+	channel, _ := i.GetChannel()
+	return author.Permissions(channel)
+}
+
+func (h *Harmonia) botMember(guildID string) (*discordgo.Member, error) {
+	if member, err := h.state().Member(guildID, h.appID()); err == nil {
+		return member, nil
+	}
+
+	member, err := h.RESTClient().GuildMember(guildID, h.appID())
+	if err != nil {
+		return nil, err
+	}
+	member.GuildID = guildID
+	return member, nil
+}
+
+// replyError tells the invoker something went wrong, privately if the Invocation allows it.
+// An Invocation from an event can not be replied to, so the error is only logged.
+func (h *Harmonia) replyError(i *Invocation, content string) {
+	if i.event != "" {
+		h.logger().Debug("event listener failed", i.logFields("event", i.event, "error", content)...)
+		return
+	}
+
+	var err error
+	if i.TextMessage != nil {
+		_, err = h.RESTClient().ChannelMessageSendComplex(i.ChannelID, &discordgo.MessageSend{
+			Content:         content,
+			Reference:       i.TextMessage.Reference(),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+	} else {
+		_, err = h.EphemeralRespond(i, content)
+	}
+
+	if err != nil {
+		h.logger().Error("could not reply with error", i.logFields("error", err)...)
+	}
+}
+
+type arg struct {
+	value  string
+	start  int
+	quoted bool
+}
+
+var errUnclosedQuote = errors.New("unclosed quote")
+
+// namedArgRegex matches the name of an argument given by name, after which its value can be quoted, such as `reason:"being rude"`.
+var namedArgRegex = regexp.MustCompile(`^[-_\p{L}\p{N}]+:$`)
+
+// splitArgs splits the content into whitespace separated arguments. Arguments can be quoted with double or single quotes,
+// in which a backslash escapes the next character.
+func splitArgs(content string) ([]arg, error) {
+	args := make([]arg, 0)
+	runes := []rune(content)
+
+	for n := 0; n < len(runes); {
+		if unicode.IsSpace(runes[n]) {
+			n++
+			continue
+		}
+
+		a := arg{start: len(string(runes[:n]))}
+		var b strings.Builder
+
+		for n < len(runes) && !unicode.IsSpace(runes[n]) {
+			quote := runes[n]
+			if (quote != '"' && quote != '\'') || (b.Len() > 0 && !namedArgRegex.MatchString(b.String())) {
+				b.WriteRune(runes[n])
+				n++
+				continue
+			}
+
+			a.quoted = a.quoted || b.Len() == 0
+			n++
+			closed := false
+			for ; n < len(runes); n++ {
+				if runes[n] == '\\' && n+1 < len(runes) {
+					n++
+					b.WriteRune(runes[n])
+					continue
+				}
+				if runes[n] == quote {
+					closed = true
+					n++
+					break
+				}
+				b.WriteRune(runes[n])
+			}
+
+			if !closed {
+				return nil, errUnclosedQuote
+			}
+		}
+
+		a.value = b.String()
+		args = append(args, a)
+	}
+	return args, nil
+}
+
+// parseArgs converts the arguments to options of the types of the given options.
+// Arguments given by name are matched to the option with that name, the others are matched in order.
+func parseArgs(content string, args []arg, options []*Option) ([]*discordgo.ApplicationCommandInteractionDataOption, error) {
+	named := make(map[string]string)
+	positional := make([]arg, 0, len(args))
+	for _, a := range args {
+		if name, value, ok := strings.Cut(a.value, ":"); ok && !a.quoted {
+			if option := findOption(options, name); option != nil {
+				if _, ok := named[option.Name]; ok {
+					return nil, fmt.Errorf("argument '%v' is given more than once", option.Name)
+				}
+				named[option.Name] = value
+				continue
+			}
+		}
+		positional = append(positional, a)
+	}
+
+	last := -1
+	for n, option := range options {
+		if _, ok := named[option.Name]; !ok {
+			last = n
+		}
+	}
+
+	parsed := make([]*discordgo.ApplicationCommandInteractionDataOption, 0, len(options))
+	rest := false
+	for n, option := range options {
+		raw, ok := named[option.Name]
+		if !ok {
+			if len(positional) == 0 {
+				if option.Required {
+					return nil, fmt.Errorf("missing argument '%v'", option.Name)
+				}
+				continue
+			}
+
+			raw = positional[0].value
+			if n == last && option.Type == discordgo.ApplicationCommandOptionString && len(positional) > 1 {
+				raw = restOfArgs(content, positional, len(named) == 0)
+				rest = true
+			}
+			positional = positional[1:]
+		}
+
+		value, err := convertArg(option, raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument '%v': %v", option.Name, err)
+		}
+
+		parsed = append(parsed, &discordgo.ApplicationCommandInteractionDataOption{
+			Name:  option.Name,
+			Type:  option.Type,
+			Value: value,
+		})
+	}
+
+	if len(positional) > 0 && !rest {
+		return nil, errors.New("too many arguments")
+	}
+
+	return parsed, nil
+}
+
+// restOfArgs returns the remaining arguments as one. When they are the end of the content, it is returned as it was written.
+func restOfArgs(content string, args []arg, atEnd bool) string {
+	if atEnd {
+		return strings.TrimSpace(content[args[0].start:])
+	}
+
+	values := make([]string, len(args))
+	for n, a := range args {
+		values[n] = a.value
+	}
+	return strings.Join(values, " ")
+}
+
+func findOption(options []*Option, name string) *Option {
+	for _, option := range options {
+		if strings.EqualFold(option.Name, name) {
+			return option
+		}
+	}
+	return nil
+}
+
+var (
+	userMentionRegex    = regexp.MustCompile(`^<@!?(\d+)>$`)
+	roleMentionRegex    = regexp.MustCompile(`^<@&(\d+)>$`)
+	channelMentionRegex = regexp.MustCompile(`^<#(\d+)>$`)
+	snowflakeRegex      = regexp.MustCompile(`^\d+$`)
+)
+
+// convertArg converts an argument to the value Discord would have sent for an option of the same type.
+func convertArg(option *Option, raw string) (interface{}, error) {
+	for _, choice := range option.Choices {
+		if strings.EqualFold(choice.Name, raw) {
+			return normalizeValue(choice.Value), nil
+		}
+	}
+
+	var value interface{}
+
+	switch option.Type {
+	case discordgo.ApplicationCommandOptionString:
+		value = raw
+	case discordgo.ApplicationCommandOptionInteger:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, errors.New("not a whole number")
+		}
+		value = float64(v)
+	case discordgo.ApplicationCommandOptionNumber:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, errors.New("not a number")
+		}
+		value = v
+	case discordgo.ApplicationCommandOptionBoolean:
+		switch strings.ToLower(raw) {
+		case "true", "yes", "y", "on", "1":
+			value = true
+		case "false", "no", "n", "off", "0":
+			value = false
+		default:
+			return nil, errors.New("not true or false")
+		}
+	case discordgo.ApplicationCommandOptionUser:
+		return mentionID(raw, "a user", userMentionRegex)
+	case discordgo.ApplicationCommandOptionRole:
+		return mentionID(raw, "a role", roleMentionRegex)
+	case discordgo.ApplicationCommandOptionChannel:
+		return mentionID(raw, "a channel", channelMentionRegex)
+	case discordgo.ApplicationCommandOptionMentionable:
+		return mentionID(raw, "a user or role", userMentionRegex, roleMentionRegex)
+	default:
+		return nil, errors.New("this type of argument can not be given in a message")
+	}
+
+	if err := checkBounds(option, value); err != nil {
+		return nil, err
+	}
+
+	if len(option.Choices) == 0 {
+		return value, nil
+	}
+
+	for _, choice := range option.Choices {
+		if normalizeValue(choice.Value) == value {
+			return value, nil
+		}
+	}
+	return nil, errors.New("not one of the choices")
+}
+
+// checkBounds checks a converted value against the minimum and maximum value or length of the option, like Discord does for slash commands.
+func checkBounds(option *Option, value interface{}) error {
+	switch v := value.(type) {
+	case float64:
+		if option.MinValue != nil && v < *option.MinValue {
+			return fmt.Errorf("has to be at least %v", *option.MinValue)
+		}
+		if option.MaxValue != 0 && v > option.MaxValue {
+			return fmt.Errorf("has to be at most %v", option.MaxValue)
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if option.MinLength != nil && length < *option.MinLength {
+			return fmt.Errorf("has to be at least %v characters", *option.MinLength)
+		}
+		if option.MaxLength != 0 && length > option.MaxLength {
+			return fmt.Errorf("has to be at most %v characters", option.MaxLength)
+		}
+	}
+	return nil
+}
+
+// normalizeValue converts numbers to float64, which is how Discord sends the values of integer and number options.
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	}
+	return value
+}
+
+func mentionID(raw, kind string, mentionRegexes ...*regexp.Regexp) (string, error) {
+	if snowflakeRegex.MatchString(raw) {
+		return raw, nil
+	}
+
+	for _, mentionRegex := range mentionRegexes {
+		if match := mentionRegex.FindStringSubmatch(raw); match != nil {
+			return match[1], nil
+		}
+	}
+	return "", fmt.Errorf("not %v", kind)
+}
@@ -0,0 +1,194 @@
+package harmonia
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func argValues(args []arg) []string {
+	values := make([]string, len(args))
+	for i, a := range args {
+		values[i] = a.value
+	}
+	return values
+}
+
+func TestSplitArgs(t *testing.T) {
+	args, err := splitArgs(`ban  <@123> "being \"rude\"" 'in general'`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ban", "<@123>", `being "rude"`, "in general"}, argValues(args))
+	assert.Equal(t, 5, args[1].start)
+
+	args, err = splitArgs("  ")
+	assert.Nil(t, err)
+	assert.Len(t, args, 0)
+
+	_, err = splitArgs(`say "hello`)
+	assert.Equal(t, errUnclosedQuote, err)
+
+	args, err = splitArgs(`reason:"being rude" don't "a:b"`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"reason:being rude", "don't", "a:b"}, argValues(args))
+	assert.False(t, args[0].quoted)
+	assert.True(t, args[2].quoted)
+}
+
+func TestTrimPrefix(t *testing.T) {
+	content, prefix, ok := trimPrefix("!!ping", []string{"!", "!!"})
+	assert.True(t, ok)
+	assert.Equal(t, "!!", prefix)
+	assert.Equal(t, "ping", content)
+
+	content, _, ok = trimPrefix("<@100> ping", []string{"<@100>"})
+	assert.True(t, ok)
+	assert.Equal(t, "ping", content)
+
+	_, _, ok = trimPrefix("ping", []string{"!", ""})
+	assert.False(t, ok)
+}
+
+func TestParseArgs(t *testing.T) {
+	options := []*Option{
+		NewOption("user", discordgo.ApplicationCommandOptionUser).IsRequired(),
+		NewOption("days", discordgo.ApplicationCommandOptionInteger).IsRequired(),
+		NewOption("silent", discordgo.ApplicationCommandOptionBoolean),
+		NewOption("reason", discordgo.ApplicationCommandOptionString),
+	}
+
+	content := `<@!123> 7 yes being rude "again"`
+	args, _ := splitArgs(content)
+	parsed, err := parseArgs(content, args, options)
+	assert.Nil(t, err)
+	assert.Len(t, parsed, 4)
+	assert.Equal(t, "123", parsed[0].Value)
+	assert.Equal(t, int64(7), parsed[1].IntValue())
+	assert.Equal(t, true, parsed[2].BoolValue())
+	assert.Equal(t, `being rude "again"`, parsed[3].StringValue())
+
+	content = "123"
+	args, _ = splitArgs(content)
+	_, err = parseArgs(content, args, options)
+	assert.EqualError(t, err, "missing argument 'days'")
+
+	content = "123 seven"
+	args, _ = splitArgs(content)
+	_, err = parseArgs(content, args, options)
+	assert.EqualError(t, err, "invalid argument 'days': not a whole number")
+
+	content = "1 yes 3"
+	args, _ = splitArgs(content)
+	_, err = parseArgs(content, args, options[1:3])
+	assert.EqualError(t, err, "too many arguments")
+}
+
+func TestParseNamedArgs(t *testing.T) {
+	options := []*Option{
+		NewOption("user", discordgo.ApplicationCommandOptionUser).IsRequired(),
+		NewOption("reason", discordgo.ApplicationCommandOptionString),
+		NewOption("days", discordgo.ApplicationCommandOptionInteger),
+	}
+
+	content := `Days:3 <@123> reason:"being rude"`
+	args, _ := splitArgs(content)
+	parsed, err := parseArgs(content, args, options)
+	assert.Nil(t, err)
+	assert.Len(t, parsed, 3)
+	assert.Equal(t, "123", parsed[0].Value)
+	assert.Equal(t, "being rude", parsed[1].StringValue())
+	assert.Equal(t, int64(3), parsed[2].IntValue())
+
+	content = `123 "days:3" days:2`
+	args, _ = splitArgs(content)
+	parsed, err = parseArgs(content, args, options[:2])
+	assert.Nil(t, err)
+	assert.Equal(t, `"days:3" days:2`, parsed[1].StringValue())
+
+	content = `user:1 user:2`
+	args, _ = splitArgs(content)
+	_, err = parseArgs(content, args, options)
+	assert.EqualError(t, err, "argument 'user' is given more than once")
+}
+
+func TestConvertArg(t *testing.T) {
+	role := NewOption("role", discordgo.ApplicationCommandOptionRole)
+	v, err := convertArg(role, "<@&42>")
+	assert.Nil(t, err)
+	assert.Equal(t, "42", v)
+	_, err = convertArg(role, "<@42>")
+	assert.EqualError(t, err, "not a role")
+
+	channel := NewOption("channel", discordgo.ApplicationCommandOptionChannel)
+	v, _ = convertArg(channel, "<#42>")
+	assert.Equal(t, "42", v)
+
+	mentionable := NewOption("target", discordgo.ApplicationCommandOptionMentionable)
+	v, _ = convertArg(mentionable, "<@&42>")
+	assert.Equal(t, "42", v)
+
+	number := NewOption("amount", discordgo.ApplicationCommandOptionNumber)
+	v, _ = convertArg(number, "2.5")
+	assert.Equal(t, 2.5, v)
+
+	size := NewOption("size", discordgo.ApplicationCommandOptionInteger).AddChoice("small", 1).AddChoice("large", 3)
+	v, err = convertArg(size, "3")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(3), v)
+	v, err = convertArg(size, "Small")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1), v)
+	_, err = convertArg(size, "2")
+	assert.EqualError(t, err, "not one of the choices")
+
+	_, err = convertArg(NewOption("file", discordgo.ApplicationCommandOptionAttachment), "file.png")
+	assert.NotNil(t, err)
+
+	days := NewOption("days", discordgo.ApplicationCommandOptionInteger).WithMinValue(1).WithMaxValue(7)
+	_, err = convertArg(days, "0")
+	assert.EqualError(t, err, "has to be at least 1")
+	_, err = convertArg(days, "8")
+	assert.EqualError(t, err, "has to be at most 7")
+
+	reason := NewOption("reason", discordgo.ApplicationCommandOptionString).WithMinLength(3).WithMaxLength(5)
+	_, err = convertArg(reason, "no")
+	assert.EqualError(t, err, "has to be at least 3 characters")
+	v, err = convertArg(reason, "späm")
+	assert.Nil(t, err)
+	assert.Equal(t, "späm", v)
+}
+
+func TestTextCommandUsage(t *testing.T) {
+	c := NewTextCommand("ban").WithOptions(
+		NewOption("user", discordgo.ApplicationCommandOptionUser).IsRequired(),
+		NewOption("reason", discordgo.ApplicationCommandOptionString),
+	)
+	assert.Equal(t, "!ban <user> [reason]", c.Usage("!"))
+}
+
+func TestAddTextCommand(t *testing.T) {
+	harm := &Harmonia{TextCommands: make(map[string]*TextCommand)}
+
+	assert.Nil(t, harm.AddTextCommand(NewTextCommand("help").WithAliases("h", "?")))
+	assert.Equal(t, harm.TextCommands["help"], harm.TextCommands["?"])
+	assert.EqualError(t, harm.AddTextCommand(NewTextCommand("h")), "text command 'h' already exists")
+
+	assert.Nil(t, harm.RemoveTextCommand("h"))
+	assert.Len(t, harm.TextCommands, 0)
+
+	assert.EqualError(t, harm.AddTextCommand(NewTextCommand("echo").WithAliases("say", "echo", "say")),
+		"text command 'echo' has more than one alias named 'echo'\ntext command 'echo' has more than one alias named 'say'")
+}
+
+func TestAddHybridCommandAliases(t *testing.T) {
+	harm := &Harmonia{Commands: make(map[string]CommandHandler), TextCommands: make(map[string]*TextCommand)}
+	ping := NewSlashCommand("ping").WithDescription("Pong")
+
+	assert.EqualError(t, harm.AddHybridCommand(ping, "p ing", "", "ping"),
+		"text command name 'p ing' contains whitespace\nempty text command name\ntext command 'ping' has more than one alias named 'ping'")
+	assert.Len(t, harm.Commands, 0, "the command is not added when its aliases are invalid")
+	assert.Len(t, harm.TextCommands, 0)
+
+	assert.Nil(t, harm.AddHybridCommand(ping, "p"))
+	assert.Equal(t, harm.TextCommands["ping"], harm.TextCommands["p"])
+}
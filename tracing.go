@@ -0,0 +1,170 @@
+package harmonia
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// A Tracer starts spans that show what Harmonia does for an Invocation, see NewMemoryTracer.
+// It mirrors the Tracer of OpenTelemetry, attributes are given as alternating keys and values like they are to a Logger,
+// so an OpenTelemetry Tracer can be used by converting them.
+//
+// Every dispatched Invocation gets a span named "harmonia.command", "harmonia.text_command", "harmonia.component" or "harmonia.autocomplete",
+// which ends once its handler has returned. Responding and resolving the guild, channel and author of the Invocation are child spans of it.
+// The span is available to handlers through Invocation.Context, so they can add spans of their own.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...any) (context.Context, Span)
+}
+
+// A Span is a timed operation started by a Tracer.
+type Span interface {
+	SetAttributes(attrs ...any)
+	RecordError(err error)
+	End()
+}
+
+// nopTracer is used when Harmonia.Tracer is not set.
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, name string, attrs ...any) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(attrs ...any) {}
+func (nopSpan) RecordError(err error)      {}
+func (nopSpan) End()                       {}
+
+// tracer returns the Tracer of Harmonia, which does not record spans unless Tracer is set.
+func (h *Harmonia) tracer() Tracer {
+	if h.Tracer == nil {
+		return nopTracer{}
+	}
+	return h.Tracer
+}
+
+// startInvocationSpan starts the span of a dispatched Invocation, which is ended by endInvocationSpan.
+func (h *Harmonia) startInvocationSpan(i *Invocation, name string, attrs ...any) {
+	i.ctx, i.span = h.tracer().Start(context.Background(), name, i.logFields(attrs...)...)
+}
+
+// endInvocationSpan ends the span of the Invocation. When a handler was invoked for it, invoke does so once the handler has returned.
+func (i *Invocation) endInvocationSpan() {
+	if i.span != nil {
+		i.span.End()
+	}
+}
+
+// startSpan starts a child span of the Invocation.
+func (i *Invocation) startSpan(name string) Span {
+	if i.h == nil {
+		return nopSpan{}
+	}
+	_, span := i.h.tracer().Start(i.Context(), name)
+	return span
+}
+
+// endSpan records the error on the span, if any, and ends it.
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// NewMemoryTracer returns a MemoryTracer without any spans.
+func NewMemoryTracer() *MemoryTracer {
+	return &MemoryTracer{}
+}
+
+// A MemoryTracer is a Tracer that keeps the spans that have ended in memory, so they can be inspected in tests.
+type MemoryTracer struct {
+	mu     sync.Mutex
+	nextID uint64
+	spans  []*RecordedSpan
+}
+
+// A RecordedSpan is a span recorded by a MemoryTracer.
+type RecordedSpan struct {
+	Name string
+	// TraceID is shared by a span and all of its descendants, SpanID is unique. ParentID is 0 for a root span.
+	TraceID    uint64
+	SpanID     uint64
+	ParentID   uint64
+	Attributes map[string]any
+	Errors     []error
+	StartTime  time.Time
+	EndTime    time.Time
+
+	tracer *MemoryTracer
+}
+
+type spanContextKey struct{}
+
+// Start starts a span, which is a child of the span in ctx started by the same MemoryTracer, if there is one.
+func (t *MemoryTracer) Start(ctx context.Context, name string, attrs ...any) (context.Context, Span) {
+	t.mu.Lock()
+	t.nextID++
+	span := &RecordedSpan{Name: name, SpanID: t.nextID, TraceID: t.nextID, Attributes: make(map[string]any), StartTime: time.Now(), tracer: t}
+	t.mu.Unlock()
+
+	if parent, ok := ctx.Value(spanContextKey{}).(*RecordedSpan); ok && parent.tracer == t {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+	}
+	span.SetAttributes(attrs...)
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// Spans returns the spans that have ended, in the order they ended.
+func (t *MemoryTracer) Spans() []*RecordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*RecordedSpan(nil), t.spans...)
+}
+
+// Find returns the spans that have ended with the given name.
+func (t *MemoryTracer) Find(name string) []*RecordedSpan {
+	spans := make([]*RecordedSpan, 0)
+	for _, span := range t.Spans() {
+		if span.Name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// Reset removes all spans that have ended.
+func (t *MemoryTracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = nil
+}
+
+func (s *RecordedSpan) SetAttributes(attrs ...any) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	for n := 0; n+1 < len(attrs); n += 2 {
+		s.Attributes[fmt.Sprint(attrs[n])] = attrs[n+1]
+	}
+}
+
+func (s *RecordedSpan) RecordError(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.Errors = append(s.Errors, err)
+}
+
+// End ends the span, after which it is returned by Spans. Ending a span more than once has no effect.
+func (s *RecordedSpan) End() {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	if !s.EndTime.IsZero() {
+		return
+	}
+	s.EndTime = time.Now()
+	s.tracer.spans = append(s.tracer.spans, s)
+}
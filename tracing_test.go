@@ -0,0 +1,94 @@
+package harmonia
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceDispatch(t *testing.T) {
+	tracer := NewMemoryTracer()
+	harm := &Harmonia{
+		REST:     &recordingREST{},
+		Tracer:   tracer,
+		Commands: make(map[string]CommandHandler),
+	}
+	harm.AddCommand(NewGroupSlashCommand("admin").WithDescription("Moderation").WithSubCommands(
+		NewSlashCommand("ban").WithDescription("Bans a user").WithCommand(func(h *Harmonia, i *Invocation) {
+			i.GetChannel()
+			_, span := h.Tracer.Start(i.Context(), "ban")
+			span.End()
+			h.Respond(i, "banned")
+		}),
+		NewSlashCommand("kick").WithDescription("Kicks a user").WithGuards(func(h *Harmonia, i *Invocation) error {
+			return errors.New("no kicking")
+		}),
+	))
+
+	dispatch := func(subcommand string) {
+		harm.Dispatch(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			ID:   subcommand,
+			Type: discordgo.InteractionApplicationCommand,
+			Data: discordgo.ApplicationCommandInteractionData{Name: "admin", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: subcommand, Type: discordgo.ApplicationCommandOptionSubCommand},
+			}},
+		}})
+		harm.Wait()
+	}
+
+	dispatch("ban")
+	commands := tracer.Find("harmonia.command")
+	assert.Len(t, commands, 1)
+	root := commands[0]
+	assert.Equal(t, uint64(0), root.ParentID)
+	assert.Equal(t, "ban", root.Attributes["interaction_id"])
+	assert.Equal(t, "admin ban", root.Attributes["command"])
+
+	for _, name := range []string{"harmonia.resolve_channel", "ban", "harmonia.respond"} {
+		spans := tracer.Find(name)
+		assert.Len(t, spans, 1, name)
+		assert.Equal(t, root.SpanID, spans[0].ParentID, name)
+		assert.Equal(t, root.TraceID, spans[0].TraceID, name)
+	}
+	assert.Equal(t, []error{discordgo.ErrStateNotFound}, tracer.Find("harmonia.resolve_channel")[0].Errors)
+	assert.Equal(t, "harmonia.command", tracer.Spans()[len(tracer.Spans())-1].Name, "the span of the Invocation ends last")
+
+	tracer.Reset()
+	dispatch("kick")
+	commands = tracer.Find("harmonia.command")
+	assert.Len(t, commands, 1)
+	assert.Equal(t, "no kicking", commands[0].Attributes["guard_error"])
+	assert.Len(t, tracer.Find("harmonia.respond"), 1)
+}
+
+func TestTraceEagerResolve(t *testing.T) {
+	tracer := NewMemoryTracer()
+	harm, err := New("token")
+	assert.Nil(t, err)
+	harm.Client = &http.Client{Transport: &countingTransport{}}
+	harm.Tracer = tracer
+	harm.EagerResolve = true
+	harm.AddCommand(NewSlashCommand("ping").WithDescription("Pong").WithCommand(func(h *Harmonia, i *Invocation) {
+		h.Respond(i, "pong")
+	}))
+
+	harm.Dispatch(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		Data:      discordgo.ApplicationCommandInteractionData{Name: "ping"},
+		GuildID:   "guild",
+		ChannelID: "channel",
+		User:      &discordgo.User{ID: "user"},
+	}})
+	harm.Wait()
+
+	commands := tracer.Find("harmonia.command")
+	assert.Len(t, commands, 1)
+	for _, name := range []string{"harmonia.resolve_guild", "harmonia.resolve_channel", "harmonia.resolve_author"} {
+		spans := tracer.Find(name)
+		assert.Len(t, spans, 1, name)
+		assert.Equal(t, commands[0].SpanID, spans[0].ParentID, name)
+	}
+}
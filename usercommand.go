@@ -12,23 +12,29 @@ type UserCommand struct {
 	guildID            string
 	dmPermission       bool
 	defaultPermissions *int64
+	guards             []Guard
 
 	commandFunc CommandFunc
 
 	registration *discordgo.ApplicationCommand
 }
 
-// NewUserCommand returns a UserCommand with a given name
+// NewUserCommand returns a UserCommand with a given name.
+// The name is validated when the UserCommand is added with AddCommand, see MustNewUserCommand to validate it right away.
 func NewUserCommand(name string) *UserCommand {
-	if name == "" {
-		log.Panic("empty command name")
-	}
-
 	return &UserCommand{
 		name: name,
 	}
 }
 
+// MustNewUserCommand does the same as NewUserCommand, but panics if the name is not valid.
+func MustNewUserCommand(name string) *UserCommand {
+	if errs := validateContextMenuName(name); len(errs) > 0 {
+		log.Panic(errs[0])
+	}
+	return NewUserCommand(name)
+}
+
 // WithGuildID changes the guildID of the UserCommand and returns itself, so that it can be chained.
 func (s *UserCommand) WithGuildID(guildID string) *UserCommand {
 	s.guildID = guildID
@@ -53,19 +59,33 @@ func (s *UserCommand) WithCommand(commandFunc CommandFunc) *UserCommand {
 	return s
 }
 
+// WithGuards adds guards that have to pass before the UserCommand is executed and returns itself, so that it can be chained.
+func (s *UserCommand) WithGuards(guards ...Guard) *UserCommand {
+	s.guards = append(s.guards, guards...)
+	return s
+}
+
+// WithBotPermissions makes the UserCommand check if the bot has the given permissions before it is executed and returns itself, so that it can be chained.
+func (s *UserCommand) WithBotPermissions(perms int64) *UserCommand {
+	return s.WithGuards(RequireBotPermissions(perms))
+}
+
 func (s *UserCommand) GetName() string {
 	return s.name
 }
 
 func (s *UserCommand) Do(h *Harmonia, i *Invocation) {
-	go s.commandFunc(h, i)
+	h.invoke(i, s.guards, s.commandFunc)
 }
 
 func (s *UserCommand) getRegistration() *discordgo.ApplicationCommand {
 	if s.registration != nil {
 		return s.registration
 	}
+	return s.buildRegistration()
+}
 
+func (s *UserCommand) buildRegistration() *discordgo.ApplicationCommand {
 	return &discordgo.ApplicationCommand{
 		Name:                     s.name,
 		GuildID:                  s.guildID,
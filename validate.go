@@ -0,0 +1,272 @@
+package harmonia
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Limits Discord puts on application commands.
+const (
+	maxNameLength        = 32
+	maxDescriptionLength = 100
+	maxOptions           = 25
+	maxChoices           = 25
+	maxChoiceNameLength  = 100
+	maxOptionLength      = 6000
+)
+
+// A ValidationError contains every problem that was found when validating commands against the limits of Discord.
+type ValidationError struct {
+	Errors []error
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for n, err := range e.Errors {
+		messages[n] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Unwrap returns the errors that were found, so they can be matched with errors.Is and errors.As.
+func (e *ValidationError) Unwrap() []error {
+	return e.Errors
+}
+
+// newValidationError returns a ValidationError with the given errors, or nil if there are none.
+func newValidationError(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: errs}
+}
+
+// Validate checks every command and TextCommand added to Harmonia against the limits of Discord.
+// It returns a ValidationError containing every problem that was found, or nil if there are none.
+func (h *Harmonia) Validate() error {
+	h.handlersMu.RLock()
+	defer h.handlersMu.RUnlock()
+
+	errs := make([]error, 0)
+
+	names := make([]string, 0, len(h.Commands))
+	for name := range h.Commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		errs = append(errs, validateCommand(h.Commands[name])...)
+	}
+
+	textNames := make([]string, 0, len(h.TextCommands))
+	for name, command := range h.TextCommands {
+		if name == command.name {
+			textNames = append(textNames, name)
+		}
+	}
+	sort.Strings(textNames)
+	for _, name := range textNames {
+		errs = append(errs, h.TextCommands[name].validate()...)
+	}
+
+	return newValidationError(errs)
+}
+
+// validateCommand checks a top-level command against the limits of Discord.
+func validateCommand(command CommandHandler) []error {
+	switch c := command.(type) {
+	case *SlashCommand:
+		if err := validateChatName("command", c.name); err != nil {
+			return append([]error{err}, c.validate()...)
+		}
+		return c.validate()
+	case *GroupSlashCommand:
+		if err := validateChatName("command", c.name); err != nil {
+			return append([]error{err}, c.validate(false)...)
+		}
+		return c.validate(false)
+	case *UserCommand:
+		return validateContextMenuName(c.name)
+	case *MessageCommand:
+		return validateContextMenuName(c.name)
+	}
+	return nil
+}
+
+// validateChatName checks the name of a slash command, subcommand or option.
+func validateChatName(kind, name string) error {
+	if name == "" {
+		return fmt.Errorf("empty %v name", kind)
+	}
+
+	if !slashCommandNameRegex.MatchString(name) || strings.ToLower(name) != name {
+		return fmt.Errorf("%v name '%v' is not a valid lowercase slash command name", kind, name)
+	}
+	return nil
+}
+
+// validateContextMenuName checks the name of a user or message command, which can contain spaces and capitals.
+func validateContextMenuName(name string) []error {
+	if name == "" {
+		return []error{errors.New("empty command name")}
+	}
+
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return []error{fmt.Errorf("command name '%v' is longer than %v characters", name, maxNameLength)}
+	}
+	return nil
+}
+
+// validateTextName checks the name or alias of a TextCommand, which can not contain whitespace.
+func validateTextName(name string) error {
+	if name == "" {
+		return errors.New("empty text command name")
+	}
+
+	if strings.IndexFunc(name, unicode.IsSpace) != -1 {
+		return fmt.Errorf("text command name '%v' contains whitespace", name)
+	}
+	return nil
+}
+
+// validateDescription checks the description of a slash command, subcommand or option, which Discord requires for all of them.
+func validateDescription(name, description string) error {
+	if description == "" {
+		return fmt.Errorf("empty description of '%v'", name)
+	}
+
+	if utf8.RuneCountInString(description) > maxDescriptionLength {
+		return fmt.Errorf("description of '%v' is longer than %v characters", name, maxDescriptionLength)
+	}
+	return nil
+}
+
+// validateOptions checks the options of the command with the given name.
+// The options of a TextCommand are not registered with Discord, so they only need a description when text is false.
+func validateOptions(command string, options []*Option, text bool) []error {
+	errs := make([]error, 0)
+
+	if len(options) > maxOptions {
+		errs = append(errs, fmt.Errorf("'%v' has %v options, at most %v are allowed", command, len(options), maxOptions))
+	}
+
+	names := make(map[string]bool, len(options))
+	optional := false
+	for _, option := range options {
+		if err := validateChatName("option", option.Name); err != nil {
+			errs = append(errs, fmt.Errorf("%v of '%v'", err, command))
+			continue
+		}
+
+		if names[option.Name] {
+			errs = append(errs, fmt.Errorf("'%v' has more than one option named '%v'", command, option.Name))
+		}
+		names[option.Name] = true
+
+		if option.Type == discordgo.ApplicationCommandOptionSubCommand || option.Type == discordgo.ApplicationCommandOptionSubCommandGroup {
+			errs = append(errs, fmt.Errorf("option '%v' of '%v' is a subcommand, use a GroupSlashCommand instead", option.Name, command))
+			continue
+		}
+
+		if option.Required && optional {
+			errs = append(errs, fmt.Errorf("required option '%v' of '%v' comes after an optional option", option.Name, command))
+		}
+		optional = optional || !option.Required
+
+		if !text || option.Description != "" {
+			if err := validateDescription(option.Name, option.Description); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		errs = append(errs, option.validate(command)...)
+	}
+	return errs
+}
+
+// validate checks the constraints and choices of the Option of the command with the given name.
+func (o *Option) validate(command string) []error {
+	errs := make([]error, 0)
+
+	numeric := o.Type == discordgo.ApplicationCommandOptionInteger || o.Type == discordgo.ApplicationCommandOptionNumber
+	if (o.MinValue != nil || o.MaxValue != 0) && !numeric {
+		errs = append(errs, fmt.Errorf("option '%v' of '%v' can not have a minimum or maximum value", o.Name, command))
+	}
+	if o.MinValue != nil && o.MaxValue != 0 && *o.MinValue > o.MaxValue {
+		errs = append(errs, fmt.Errorf("minimum value of option '%v' of '%v' is larger than its maximum value", o.Name, command))
+	}
+
+	if (o.MinLength != nil || o.MaxLength != 0) && o.Type != discordgo.ApplicationCommandOptionString {
+		errs = append(errs, fmt.Errorf("option '%v' of '%v' can not have a minimum or maximum length", o.Name, command))
+	}
+	if (o.MinLength != nil && (*o.MinLength < 0 || *o.MinLength > maxOptionLength)) || o.MaxLength < 0 || o.MaxLength > maxOptionLength {
+		errs = append(errs, fmt.Errorf("length of option '%v' of '%v' has to be between 0 and %v", o.Name, command, maxOptionLength))
+	}
+	if o.MinLength != nil && o.MaxLength != 0 && *o.MinLength > o.MaxLength {
+		errs = append(errs, fmt.Errorf("minimum length of option '%v' of '%v' is larger than its maximum length", o.Name, command))
+	}
+
+	if len(o.ChannelTypes) > 0 && o.Type != discordgo.ApplicationCommandOptionChannel {
+		errs = append(errs, fmt.Errorf("option '%v' of '%v' can not have channel types", o.Name, command))
+	}
+
+	if len(o.Choices) == 0 && len(o.rejected) == 0 {
+		return errs
+	}
+
+	if !numeric && o.Type != discordgo.ApplicationCommandOptionString {
+		return append(errs, fmt.Errorf("option '%v' of '%v' can not have choices", o.Name, command))
+	}
+
+	if o.Autocomplete {
+		errs = append(errs, fmt.Errorf("option '%v' of '%v' can not have both choices and autocomplete", o.Name, command))
+	}
+
+	if len(o.Choices) > maxChoices {
+		errs = append(errs, fmt.Errorf("option '%v' of '%v' has %v choices, at most %v are allowed", o.Name, command, len(o.Choices), maxChoices))
+	}
+
+	choices := append(append(make([]*discordgo.ApplicationCommandOptionChoice, 0, len(o.Choices)+len(o.rejected)), o.Choices...), o.rejected...)
+	for _, choice := range choices {
+		if choice.Name == "" || utf8.RuneCountInString(choice.Name) > maxChoiceNameLength {
+			errs = append(errs, fmt.Errorf("choice name '%v' of option '%v' of '%v' has to be between 1 and %v characters", choice.Name, o.Name, command, maxChoiceNameLength))
+		}
+
+		if !choiceMatchesType(o.Type, choice.Value) {
+			errs = append(errs, fmt.Errorf("choice '%v' of option '%v' of '%v' has a value of type %T, which does not match the option type", choice.Name, o.Name, command, choice.Value))
+		}
+	}
+	return errs
+}
+
+// choiceMatchesType returns whether a choice value can be sent for an option of the given type.
+func choiceMatchesType(t discordgo.ApplicationCommandOptionType, value interface{}) bool {
+	switch t {
+	case discordgo.ApplicationCommandOptionString:
+		_, ok := value.(string)
+		return ok
+	case discordgo.ApplicationCommandOptionInteger:
+		return isInteger(value)
+	case discordgo.ApplicationCommandOptionNumber:
+		switch value.(type) {
+		case float32, float64:
+			return true
+		}
+		return isInteger(value)
+	}
+	return false
+}
+
+func isInteger(value interface{}) bool {
+	switch value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package harmonia
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddCommandValidation(t *testing.T) {
+	harm := &Harmonia{Commands: make(map[string]CommandHandler)}
+
+	command := NewSlashCommand("Ban").
+		WithDescription(strings.Repeat("a", 101)).
+		WithOptions(
+			NewOption("reason", discordgo.ApplicationCommandOptionString).WithDescription("Why"),
+			NewOption("user", discordgo.ApplicationCommandOptionUser).WithDescription("Who").IsRequired(),
+			NewOption("user", discordgo.ApplicationCommandOptionUser).WithDescription("Who else"),
+			NewOption("", discordgo.ApplicationCommandOptionUser),
+			NewOption("days", discordgo.ApplicationCommandOptionInteger).WithDescription("How long").AddChoice("one", 1).AddChoice("half", 0.5),
+			NewOption("silent", discordgo.ApplicationCommandOptionBoolean).AddChoice("yes", true),
+		)
+
+	err := harm.AddCommand(command)
+	var validationErr *ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"command name 'Ban' is not a valid lowercase slash command name",
+		"description of 'Ban' is longer than 100 characters",
+		"required option 'user' of 'Ban' comes after an optional option",
+		"'Ban' has more than one option named 'user'",
+		"empty option name of 'Ban'",
+		"choice 'half' of option 'days' of 'Ban' has a value of type float64, which does not match the option type",
+		"empty description of 'silent'",
+		"option 'silent' of 'Ban' can not have choices",
+	}, strings.Split(err.Error(), "\n"))
+	assert.Len(t, harm.Commands, 0)
+
+	options := make([]*Option, 26)
+	for n := range options {
+		options[n] = NewOption(string(rune('a'+n)), discordgo.ApplicationCommandOptionString).WithDescription("Letter")
+	}
+	assert.EqualError(t, harm.AddCommand(NewSlashCommand("many").WithDescription("Many").WithOptions(options...)), "'many' has 26 options, at most 25 are allowed")
+
+	assert.EqualError(t, harm.AddCommand(NewUserCommand("")), "empty command name")
+	assert.Nil(t, harm.AddCommand(NewMessageCommand("Report Message")))
+}
+
+func TestDescriptionValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		command CommandHandler
+		err     string
+	}{
+		{"slash command", NewSlashCommand("ping"), "empty description of 'ping'"},
+		{"group", NewGroupSlashCommand("admin").WithSubCommands(NewSlashCommand("ban").WithDescription("Bans a user")), "empty description of 'admin'"},
+		{"subcommand", NewGroupSlashCommand("admin").WithDescription("Moderation").WithSubCommands(NewSlashCommand("ban")), "empty description of 'ban'"},
+		{"option", NewSlashCommand("ping").WithDescription("Pong").WithOptions(NewOption("target", discordgo.ApplicationCommandOptionUser)), "empty description of 'target'"},
+		{"user command", NewUserCommand("Inspect"), ""},
+		{"message command", NewMessageCommand("Report Message"), ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			harm := &Harmonia{Commands: make(map[string]CommandHandler)}
+			err := harm.AddCommand(test.command)
+			if test.err == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+
+	harm := &Harmonia{TextCommands: make(map[string]*TextCommand)}
+	assert.Nil(t, harm.AddTextCommand(NewTextCommand("echo").WithOptions(NewOption("text", discordgo.ApplicationCommandOptionString))), "options of text commands need no description")
+}
+
+func TestWithSubCommandsValidation(t *testing.T) {
+	harm := &Harmonia{Commands: make(map[string]CommandHandler)}
+
+	group := NewGroupSlashCommand("admin").WithDescription("Moderation").WithSubCommands(
+		NewSlashCommand("ban").WithDescription("Bans a user"),
+		NewSlashCommand("ban").WithDescription("Bans a user"),
+		NewUserCommand("kick"),
+	)
+	assert.Len(t, group.subcommands, 1)
+	assert.EqualError(t, harm.AddCommand(group), "group 'admin' has more than one subcommand named 'ban'\nsubcommand 'kick' of group 'admin' is neither a SlashCommand nor a GroupSlashCommand")
+}
+
+func TestValidate(t *testing.T) {
+	harm := &Harmonia{Commands: make(map[string]CommandHandler), TextCommands: make(map[string]*TextCommand)}
+	harm.AddCommand(NewSlashCommand("ping").WithDescription("Pong"))
+	harm.AddTextCommand(NewTextCommand("echo").WithAliases("say"))
+	assert.Nil(t, harm.Validate())
+
+	// Commands can still be changed after they have been added.
+	harm.Commands["ping"].(*SlashCommand).WithOptions(NewOption("Target", discordgo.ApplicationCommandOptionUser).WithDescription("Who"))
+	assert.EqualError(t, harm.Validate(), "option name 'Target' is not a valid lowercase slash command name of 'ping'")
+
+	assert.EqualError(t, harm.AddTextCommand(NewTextCommand("bad name").WithAliases("")), "text command name 'bad name' contains whitespace\nempty text command name")
+}
+
+func TestMustConstructors(t *testing.T) {
+	assert.Panics(t, func() { MustNewSlashCommand("Ping") })
+	assert.Panics(t, func() { MustNewGroupSlashCommand("") })
+	assert.Panics(t, func() { MustNewUserCommand("") })
+	assert.Panics(t, func() { MustNewMessageCommand(strings.Repeat("a", 33)) })
+	assert.Panics(t, func() { MustNewTextCommand("two words") })
+	assert.NotPanics(t, func() { MustNewSlashCommand("ping") })
+}